@@ -17,6 +17,7 @@ func Routes(e *echo.Echo) {
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	v1.Router(e.Group("/api/v1"))
+	v1.KubernetesApi(e.Group("/k8s/:company/:agent"))
 }
 
 func index(c echo.Context) error {
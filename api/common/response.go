@@ -2,7 +2,11 @@ package common
 
 import (
 	"github.com/labstack/echo/v4"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // MetaData Http response metadata
@@ -57,6 +61,26 @@ func GenerateForbiddenResponse(c echo.Context, data interface{}, message string)
 	})
 }
 
+// GenerateNotFoundResponse Http not found response
+func GenerateNotFoundResponse(c echo.Context, data interface{}, message string) error {
+	return c.JSON(http.StatusNotFound, ResponseDTO{
+		Status:  "error",
+		Message: message,
+		Data:    data,
+	})
+}
+
+// GenerateTooManyRequestsResponse Http too many requests response with Retry-After header
+func GenerateTooManyRequestsResponse(c echo.Context, data interface{}, message string, retryAfter time.Duration) error {
+	seconds := int64(math.Max(1, math.Ceil(retryAfter.Seconds())))
+	c.Response().Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	return c.JSON(http.StatusTooManyRequests, ResponseDTO{
+		Status:  "error",
+		Message: message,
+		Data:    data,
+	})
+}
+
 // GetPaginationMetadata return pagination metadata
 func GetPaginationMetadata(page, limit, totalRecords, totalPaginatedRecords int64) MetaData {
 	metaData := MetaData{
@@ -67,3 +91,31 @@ func GetPaginationMetadata(page, limit, totalRecords, totalPaginatedRecords int6
 	}
 	return metaData
 }
+
+// GetPaginationLinks return self, first, prev, next and last page links of a paginated request
+func GetPaginationLinks(requestUrl *url.URL, page, limit, totalRecords int64) []map[string]string {
+	link := func(p int64) string {
+		u := *requestUrl
+		query := u.Query()
+		query.Set("page", strconv.FormatInt(p, 10))
+		query.Set("limit", strconv.FormatInt(limit, 10))
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	lastPage := int64(0)
+	if limit > 0 && totalRecords > 0 {
+		lastPage = (totalRecords - 1) / limit
+	}
+	links := []map[string]string{
+		{"self": link(page)},
+		{"first": link(0)},
+	}
+	if page > 0 {
+		links = append(links, map[string]string{"prev": link(page - 1)})
+	}
+	if page < lastPage {
+		links = append(links, map[string]string{"next": link(page + 1)})
+	}
+	links = append(links, map[string]string{"last": link(lastPage)})
+	return links
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"strings"
+)
+
+func Admin(g *echo.Group) {
+	g.GET("/usage", GetUsage)
+	g.POST("/agents/:name/decommission", DecommissionAgent)
+	g.GET("/decommissions/:id", GetAgentDecommission)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting rate limit and storage quota usage
+// @Tags Admin
+// @Produce json
+// @Param company query string false "Company id"
+// @Success 200 {object} common.ResponseDTO{data=v1.Usage{}}
+// @Router /api/v1/admin/usage [GET]
+func GetUsage(context echo.Context) error {
+	return common.GenerateSuccessResponse(context, v1.GetUsage(context.QueryParam("company")), nil, "Successfully Fetched!")
+}
+
+// Post... Post Api
+// @Summary Post api
+// @Description Api for decommissioning an agent, removes every stored document of the agent
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Agent name"
+// @Param company query string true "Company id"
+// @Param policy query string false "PURGE or ARCHIVE, default PURGE"
+// @Param dry_run query bool false "Only count documents"
+// @Success 200 {object} common.ResponseDTO{data=v1.AgentDecommission{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/admin/agents/{name}/decommission [POST]
+func DecommissionAgent(context echo.Context) error {
+	dryRun := context.QueryParam("dry_run") == "true"
+	policy := enums.RETENTION_POLICY(strings.ToUpper(context.QueryParam("policy")))
+	job, err := v1.DecommissionAgent(context.QueryParam("company"), context.Param("name"), policy, dryRun)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	if dryRun {
+		return common.GenerateSuccessResponse(context, job, nil, "Successfully Counted!")
+	}
+	return common.GenerateSuccessResponse(context, job, nil, "Decommission Started!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting progress of an agent decommission
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Decommission id"
+// @Success 200 {object} common.ResponseDTO{data=v1.AgentDecommission{}}
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/admin/decommissions/{id} [GET]
+func GetAgentDecommission(context echo.Context) error {
+	job, err := v1.FindAgentDecommission(context.Param("id"))
+	if err == v1.ErrDecommissionNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, job, nil, "Successfully Fetched!")
+}
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+	"log"
+)
+
+func Agents(g *echo.Group) {
+	g.POST("", RegisterAgent)
+	g.GET("", GetAgents)
+	g.GET("/:name", GetAgent)
+	g.PUT("/:name", UpdateAgent)
+	g.DELETE("/:name", DeleteAgent)
+	g.POST("/:name/heartbeats", AgentHeartbeat)
+}
+
+// Post... Post Api
+// @Summary Post api
+// @Description Api for registering an agent
+// @Tags Agents
+// @Produce json
+// @Param data body v1.Agent true "Agent"
+// @Success 200 {object} common.ResponseDTO{data=v1.Agent{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/agents [POST]
+func RegisterAgent(context echo.Context) error {
+	var agent v1.Agent
+	if err := context.Bind(&agent); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	registered, err := agent.Register()
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, registered, nil, "Successfully Registered!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting registered agents
+// @Tags Agents
+// @Produce json
+// @Param company query string false "Company id"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.Agent{}}
+// @Router /api/v1/agents [GET]
+func GetAgents(context echo.Context) error {
+	return common.GenerateSuccessResponse(context, v1.FindAgents(context.QueryParam("company")), nil, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting a registered agent
+// @Tags Agents
+// @Produce json
+// @Param name path string true "Agent name"
+// @Param company query string true "Company id"
+// @Success 200 {object} common.ResponseDTO{data=v1.Agent{}}
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/agents/{name} [GET]
+func GetAgent(context echo.Context) error {
+	agent, err := v1.FindAgent(context.QueryParam("company"), context.Param("name"))
+	if err == v1.ErrAgentNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, agent, nil, "Successfully Fetched!")
+}
+
+// Put... Put Api
+// @Summary Put api
+// @Description Api for updating version and cluster labels of a registered agent
+// @Tags Agents
+// @Produce json
+// @Param name path string true "Agent name"
+// @Param company query string true "Company id"
+// @Param data body v1.Agent true "Agent"
+// @Success 200 {object} common.ResponseDTO{data=v1.Agent{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/agents/{name} [PUT]
+func UpdateAgent(context echo.Context) error {
+	var agent v1.Agent
+	if err := context.Bind(&agent); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	agent.CompanyId = context.QueryParam("company")
+	agent.Name = context.Param("name")
+	updated, err := agent.Update()
+	if err == v1.ErrAgentNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, updated, nil, "Successfully Updated!")
+}
+
+// Delete... Delete Api
+// @Summary Delete api
+// @Description Api for removing an agent from registry
+// @Tags Agents
+// @Produce json
+// @Param name path string true "Agent name"
+// @Param company query string true "Company id"
+// @Success 200 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/agents/{name} [DELETE]
+func DeleteAgent(context echo.Context) error {
+	err := v1.Agent{CompanyId: context.QueryParam("company"), Name: context.Param("name")}.Delete()
+	if err == v1.ErrAgentNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, nil, nil, "Successfully Deleted!")
+}
+
+// Post... Post Api
+// @Summary Post api
+// @Description Api for agent heartbeats
+// @Tags Agents
+// @Produce json
+// @Param name path string true "Agent name"
+// @Param data body v1.AgentHeartbeat true "Heartbeat"
+// @Success 200 {object} common.ResponseDTO{data=v1.Agent{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/agents/{name}/heartbeats [POST]
+func AgentHeartbeat(context echo.Context) error {
+	var heartbeat v1.AgentHeartbeat
+	if err := context.Bind(&heartbeat); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	if heartbeat.CompanyId == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	agent, err := v1.Agent{CompanyId: heartbeat.CompanyId, Name: context.Param("name")}.Heartbeat(heartbeat)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, agent, nil, "Successfully Received!")
+}
@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+	"log"
+	"net/http"
+)
+
+func Audit(g *echo.Group) {
+	g.GET("/verify", VerifyAuditLog)
+	g.GET("/export", ExportAuditLog)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for verifying hash chain of an agent's audit log
+// @Tags Audit
+// @Produce json
+// @Param company query string true "Company id"
+// @Param agent query string true "Agent name"
+// @Success 200 {object} common.ResponseDTO{data=v1.AuditVerification{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/audit/verify [GET]
+func VerifyAuditLog(context echo.Context) error {
+	companyId, agent := context.QueryParam("company"), context.QueryParam("agent")
+	if companyId == "" || agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Company and agent are required!")
+	}
+	verification, err := v1.VerifyAuditChain(companyId, agent)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, verification, nil, "Successfully Verified!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for exporting audit log of a company as newline delimited json
+// @Tags Audit
+// @Produce application/x-ndjson
+// @Param company query string true "Company id"
+// @Param agent query string false "Agent name, all agents if empty"
+// @Param from query string false "RFC3339 time, entries received at or after"
+// @Param to query string false "RFC3339 time, entries received at or before"
+// @Success 200 {object} v1.AuditEntry{}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/audit/export [GET]
+func ExportAuditLog(context echo.Context) error {
+	companyId := context.QueryParam("company")
+	if companyId == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	from, err := parseTimeParam(context, "from")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid from time: "+err.Error())
+	}
+	to, err := parseTimeParam(context, "to")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid to time: "+err.Error())
+	}
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	response.Header().Set(echo.HeaderContentDisposition, "attachment; filename=\"audit-"+companyId+".ndjson\"")
+	response.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(response)
+	err = v1.ExportAuditLog(companyId, context.QueryParam("agent"), from, to, func(entry v1.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to export audit log:", err.Error())
+	}
+	return nil
+}
@@ -7,10 +7,27 @@ import (
 	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"github.com/labstack/echo/v4"
 	"log"
+	"net/http"
+	"strconv"
+	"time"
 )
 
 func Router(g *echo.Group) {
 	KubeEvents(g.Group("/kube_events"))
+	RedactionPolicies(g.Group("/redaction_policies"))
+	Admin(g.Group("/admin"))
+	Agents(g.Group("/agents"))
+	Resources(g.Group("/resources"))
+	Revisions(g.Group("/revisions"))
+	Changes(g.Group("/changes"))
+	Audit(g.Group("/audit"))
+	Workloads(g.Group("/workloads"))
+	Services(g.Group("/services"))
+	Ingresses(g.Group("/ingresses"))
+	Graphs(g.Group("/graph"))
+	ConfigUsage(g.Group("/config_usage"))
+	Pipelines(g.Group("/pipelines"))
+	Webhooks(g.Group("/webhooks"))
 }
 
 func KubeEvents(g *echo.Group) {
@@ -25,6 +42,7 @@ func KubeEvents(g *echo.Group) {
 // @Success 200 {object} common.ResponseDTO{data=v1.KubeEventMessage{}.Body{}}
 // @Forbidden 403 {object} common.ResponseDTO
 // @Failure 400 {object} common.ResponseDTO
+// @Failure 429 {object} common.ResponseDTO
 // @Router /api/v1/kube_events [POST]
 func StoreKubeEvents(context echo.Context) error {
 	var kubeEvents v1.KubeEventMessage
@@ -32,6 +50,45 @@ func StoreKubeEvents(context echo.Context) error {
 		log.Println("Input Error:", err.Error())
 		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
 	}
+	object := enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"])
+	agent := kubeEvents.Header.Extras["agent"]
+	meta := v1.GetObjectMeta(kubeEvents.Object())
+	companyId := meta.Labels["company"]
+	if companyId == "" {
+		companyId = v1.AgentCompany(agent)
+	}
+	receivedAt := time.Now().UTC()
+	defer func() {
+		// echo context is reused after the handler returns, entry must be built before leaving
+		status := context.Response().Status
+		if !context.Response().Committed {
+			status = http.StatusBadRequest
+		}
+		go v1.AppendAuditEntry(v1.AuditEntry{
+			CompanyId:  companyId,
+			AgentName:  agent,
+			ReceivedAt: receivedAt,
+			RemoteAddr: context.RealIP(),
+			UserAgent:  context.Request().UserAgent(),
+			Command:    kubeEvents.Header.Command,
+			Offset:     kubeEvents.Header.Offset,
+			Kind:       object,
+			Namespace:  meta.Namespace,
+			Name:       meta.Name,
+			UID:        string(meta.UID),
+			Result:     v1.AuditResult(status),
+			StatusCode: status,
+		})
+	}()
+	if allowed, retryAfter := v1.AllowKubeEvent(companyId, agent); !allowed {
+		return common.GenerateTooManyRequestsResponse(context, nil, "Rate limit exceeded!", retryAfter)
+	}
+	if kubeEvents.Header.Command != enums.DELETE {
+		if err := v1.CheckObjectQuota(object, companyId, agent, meta); err != nil {
+			return common.GenerateTooManyRequestsResponse(context, nil, err.Error(), v1.QuotaRetryAfter)
+		}
+	}
+	go v1.Agent{CompanyId: companyId, Name: agent}.RecordActivity(kubeEvents.Header.Command)
 	type TempBody struct {
 		Obj interface{} `json:"obj"`
 	}
@@ -52,9 +109,9 @@ func StoreKubeEvents(context echo.Context) error {
 			return common.GenerateErrorResponse(context, nil, err.Error())
 		}
 		var oldKubeObject v1.KubeObject
-		oldKubeObject = v1.GetObject(enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"]))
+		oldKubeObject = v1.GetObject(object)
 		var newKubeObject v1.KubeObject
-		newKubeObject = v1.GetObject(enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"]))
+		newKubeObject = v1.GetObject(object)
 
 		var tempOldBody TempBody
 		tempOldBody.Obj = body.OldK8sObj
@@ -74,14 +131,23 @@ func StoreKubeEvents(context echo.Context) error {
 			log.Println("marshaling error: ", err.Error())
 			log.Println(err.Error())
 		}
-		err = newKubeObject.Update(oldKubeObject, kubeEvents.Header.Extras["agent"])
+		err = newKubeObject.Update(oldKubeObject, agent)
 		if err != nil {
 			return common.GenerateErrorResponse(context, nil, err.Error())
 		}
+		go v1.OnKubeEventApplied(v1.AppliedKubeEvent{
+			Type:      object,
+			Command:   enums.UPDATE,
+			Offset:    kubeEvents.Header.Offset,
+			AgentName: agent,
+			Object:    newKubeObject,
+			OldObject: oldKubeObject,
+			AppliedAt: time.Now().UTC(),
+		})
 		return common.GenerateSuccessResponse(context, newKubeObject, nil, "Successfully Updated!")
 	} else if kubeEvents.Header.Command == enums.ADD {
 		var kubeObject v1.KubeObject
-		kubeObject = v1.GetObject(enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"]))
+		kubeObject = v1.GetObject(object)
 		var tempOldBody TempBody
 		tempOldBody.Obj = kubeEvents.Body
 		old, err := json.MarshalIndent(tempOldBody, "", "  ")
@@ -102,10 +168,22 @@ func StoreKubeEvents(context echo.Context) error {
 		if err != nil {
 			return common.GenerateErrorResponse(context, nil, err.Error())
 		}
+		go v1.OnKubeEventApplied(v1.AppliedKubeEvent{
+			Type:      object,
+			Command:   enums.ADD,
+			Offset:    kubeEvents.Header.Offset,
+			AgentName: agent,
+			Object:    kubeObject,
+			AppliedAt: time.Now().UTC(),
+		})
 		return common.GenerateSuccessResponse(context, kubeEvents.Body, nil, "Successfully Added!")
 	} else if kubeEvents.Header.Command == enums.DELETE {
+		if object == enums.EVENT {
+			// events are kept until their retention elapses, deletes by kubernetes' short ttl are not applied
+			return common.GenerateSuccessResponse(context, kubeEvents.Body, nil, "Event deletes are ignored!")
+		}
 		var kubeObject v1.KubeObject
-		kubeObject = v1.GetObject(enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"]))
+		kubeObject = v1.GetObject(object)
 		var tempOldBody TempBody
 		tempOldBody.Obj = kubeEvents.Body
 		old, err := json.MarshalIndent(tempOldBody, "", "  ")
@@ -117,10 +195,21 @@ func StoreKubeEvents(context echo.Context) error {
 			log.Println("marshaling error: ", err.Error())
 			return common.GenerateErrorResponse(context, nil, err.Error())
 		}
-		err = kubeObject.Delete(kubeEvents.Header.Extras["agent"])
+		extra := make(map[string]string)
+		extra["agent_name"] = agent
+		extra["offset"] = strconv.Itoa(kubeEvents.Header.Offset)
+		err = kubeObject.Delete(extra)
 		if err != nil {
 			return common.GenerateErrorResponse(context, nil, err.Error())
 		}
+		go v1.OnKubeEventApplied(v1.AppliedKubeEvent{
+			Type:      object,
+			Command:   enums.DELETE,
+			Offset:    kubeEvents.Header.Offset,
+			AgentName: agent,
+			Object:    kubeObject,
+			AppliedAt: time.Now().UTC(),
+		})
 		return common.GenerateSuccessResponse(context, kubeEvents.Body, nil, "Successfully Deleted!")
 	}
 	return nil
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"strconv"
+	"time"
+)
+
+func Changes(g *echo.Group) {
+	g.GET("", GetChanges)
+	g.GET("/config_impact", GetConfigChangeImpact)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for listing field level changes of updated objects, e.g. image changes of a namespace in a week
+// @Tags Changes
+// @Produce json
+// @Param kind query string false "Resource type, e.g. pod, deployment, configMap"
+// @Param company query string false "Company id"
+// @Param agent query string false "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param name query string false "Object name"
+// @Param field query string false "Changed field, matches the end of change path, e.g. image or spec.replicas"
+// @Param op query string false "add, remove or replace"
+// @Param from query string false "RFC3339 time, changes recorded at or after"
+// @Param to query string false "RFC3339 time, changes recorded at or before"
+// @Param page query int64 false "Page number, starts from 0"
+// @Param limit query int64 false "Page size"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.ObjectChange{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/changes [GET]
+func GetChanges(context echo.Context) error {
+	from, err := parseTimeParam(context, "from")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid from time: "+err.Error())
+	}
+	to, err := parseTimeParam(context, "to")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid to time: "+err.Error())
+	}
+	op := context.QueryParam("op")
+	if op != "" && op != v1.ChangeAdd && op != v1.ChangeRemove && op != v1.ChangeReplace {
+		return common.GenerateErrorResponse(context, nil, "Op must be add, remove or replace!")
+	}
+	page, _ := strconv.ParseInt(context.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	if limit <= 0 {
+		limit = v1.DefaultPageLimit
+	} else if limit > v1.MaxPageLimit {
+		limit = v1.MaxPageLimit
+	}
+	if page < 0 {
+		page = 0
+	}
+	query := v1.ChangeQuery{
+		Type:      enums.RESOURCE_TYPE(context.QueryParam("kind")),
+		CompanyId: context.QueryParam("company"),
+		AgentName: context.QueryParam("agent"),
+		Namespace: context.QueryParam("namespace"),
+		Name:      context.QueryParam("name"),
+		Field:     context.QueryParam("field"),
+		Op:        op,
+		From:      from,
+		To:        to,
+		Page:      page,
+		Limit:     limit,
+	}
+	changes, total, err := v1.FindChanges(query)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	metadata := common.GetPaginationMetadata(page, limit, total, int64(len(changes)))
+	metadata.Links = common.GetPaginationLinks(context.Request().URL, page, limit, total)
+	return common.GenerateSuccessResponse(context, changes, &metadata, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for correlating configmap and secret changes with restarts and crash loops of pods using them within a window following the change
+// @Tags Changes
+// @Produce json
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param from query string false "RFC3339 time, changes recorded at or after, default a day before to"
+// @Param to query string false "RFC3339 time, changes recorded at or before, default now"
+// @Param window query string false "Duration after a change restarts are attributed to it, e.g. 15m, default 10m"
+// @Param only_impacting query bool false "Only changes followed by restarts or crash loops"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.ConfigChangeImpact{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/changes/config_impact [GET]
+func GetConfigChangeImpact(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	if agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	from, err := parseTimeParam(context, "from")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid from time: "+err.Error())
+	}
+	to, err := parseTimeParam(context, "to")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid to time: "+err.Error())
+	}
+	var window time.Duration
+	if value := context.QueryParam("window"); value != "" {
+		window, err = time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			return common.GenerateErrorResponse(context, nil, "Invalid window!")
+		}
+	}
+	onlyImpacting, _ := strconv.ParseBool(context.QueryParam("only_impacting"))
+	impacts, err := v1.FindConfigChangeImpacts(v1.ConfigImpactQuery{
+		CompanyId:     context.QueryParam("company"),
+		AgentName:     agent,
+		Namespace:     context.QueryParam("namespace"),
+		From:          from,
+		To:            to,
+		Window:        window,
+		OnlyImpacting: onlyImpacting,
+	})
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, impacts, nil, "Successfully Fetched!")
+}
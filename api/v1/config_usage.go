@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+)
+
+func ConfigUsage(g *echo.Group) {
+	g.GET("", GetConfigUsage)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for mapping configmaps and secrets to pods using them, reporting unused ones and references to missing objects or keys
+// @Tags ConfigUsage
+// @Produce json
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace, all namespaces if empty"
+// @Success 200 {object} common.ResponseDTO{data=v1.ConfigUsageReport{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/config_usage [GET]
+func GetConfigUsage(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	if agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	report, err := v1.AnalyzeConfigUsage(context.QueryParam("company"), agent, context.QueryParam("namespace"))
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, report, nil, "Successfully Fetched!")
+}
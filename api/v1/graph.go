@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+func Graphs(g *echo.Group) {
+	g.GET("", GetGraph)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for exporting dependency graph of a namespace or cluster, with nodes of workloads, pods, services, ingresses, configmaps, secrets, pvcs, pvs and nodes and edges owns, selects, mounts, routes-to, scheduled-on and binds-to
+// @Tags Graph
+// @Produce json
+// @Produce text/vnd.graphviz
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace, whole cluster if empty"
+// @Param format query string false "json or dot, default json"
+// @Success 200 {object} common.ResponseDTO{data=v1.Graph{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/graph [GET]
+func GetGraph(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	if agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	format := context.QueryParam("format")
+	if format == "" {
+		format = v1.GraphFormatJSON
+	}
+	if format != v1.GraphFormatJSON && format != v1.GraphFormatDOT {
+		return common.GenerateErrorResponse(context, nil, v1.ErrUnknownGraphFormat.Error())
+	}
+	namespace := context.QueryParam("namespace")
+	graph, err := v1.BuildGraph(context.QueryParam("company"), agent, namespace)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	if format == v1.GraphFormatDOT {
+		name := agent
+		if namespace != "" {
+			name = agent + "/" + namespace
+		}
+		return context.Blob(http.StatusOK, "text/vnd.graphviz; charset=utf-8", graph.DOT(name))
+	}
+	return common.GenerateSuccessResponse(context, graph, nil, "Successfully Fetched!")
+}
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+)
+
+func Ingresses(g *echo.Group) {
+	g.GET("/topology", GetIngressTopology)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for listing every external host and path routed by ingresses, with backend service, port and pods, flagging missing services, unknown ports and missing tls secrets
+// @Tags Ingresses
+// @Produce json
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace, all namespaces if empty"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.IngressRoute{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/ingresses/topology [GET]
+func GetIngressTopology(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	if agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	routes, err := v1.FindIngressTopology(context.QueryParam("company"), agent, context.QueryParam("namespace"))
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, routes, nil, "Successfully Fetched!")
+}
@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"encoding/json"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KubernetesApi read only kubernetes compatible api of an agent's stored objects, served if the agent is registered by
+// the company, e.g. kubectl --server http://{host}/k8s/{company}/{agent} get pods
+func KubernetesApi(g *echo.Group) {
+	g.GET("/*", ServeKubernetesApi)
+}
+
+// kubeStatus writes a kubernetes Status failure response.
+func kubeStatus(context echo.Context, code int, reason v1.StatusReason, message string) error {
+	return context.JSON(code, v1.Status{
+		TypeMeta: v1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   "Failure",
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
+
+func kubeNotFound(context echo.Context) error {
+	return kubeStatus(context, http.StatusNotFound, v1.StatusReasonNotFound, "the server could not find the requested resource")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Read only kubernetes compatible api over stored objects of an agent. Serves discovery documents (/api, /api/v1, /apis, /apis/{group}/{version}), /version, lists in kubernetes List format (/api/v1/namespaces/{ns}/pods, /apis/apps/v1/deployments), single objects and watch streams (?watch=true).
+// @Tags Kubernetes
+// @Produce json
+// @Param company path string true "Company id"
+// @Param agent path string true "Agent name"
+// @Param path path string true "Kubernetes api path, e.g. api/v1/namespaces/default/pods"
+// @Param labelSelector query string false "Label selector"
+// @Param fieldSelector query string false "Field selector"
+// @Param limit query int64 false "Page size"
+// @Param continue query string false "Continue token of the previous page"
+// @Param watch query bool false "Stream changes"
+// @Param timeoutSeconds query int64 false "Watch timeout"
+// @Success 200 {object} v1.KubeList{}
+// @Failure 400 {object} v1.Status{}
+// @Failure 404 {object} v1.Status{}
+// @Router /k8s/{company}/{agent}/{path} [GET]
+func ServeKubernetesApi(context echo.Context) error {
+	var segments []string
+	for _, each := range strings.Split(context.Param("*"), "/") {
+		if each != "" {
+			segments = append(segments, each)
+		}
+	}
+	if len(segments) == 0 {
+		return kubeNotFound(context)
+	}
+	agent, err := v1.FindAgent(context.Param("company"), context.Param("agent"))
+	if err == v1.ErrAgentNotFound {
+		return kubeNotFound(context)
+	} else if err != nil {
+		return kubeStatus(context, http.StatusInternalServerError, v1.StatusReasonInternalError, err.Error())
+	}
+	var apiVersion string
+	var rest []string
+	switch segments[0] {
+	case "version":
+		if len(segments) != 1 {
+			return kubeNotFound(context)
+		}
+		return getKubernetesVersion(context, agent)
+	case "api":
+		if len(segments) == 1 {
+			return context.JSON(http.StatusOK, v1.GetAPIVersions())
+		}
+		apiVersion, rest = segments[1], segments[2:]
+	case "apis":
+		if len(segments) == 1 {
+			return context.JSON(http.StatusOK, v1.GetAPIGroupList())
+		}
+		if len(segments) == 2 {
+			group, ok := v1.GetAPIGroup(segments[1])
+			if !ok {
+				return kubeNotFound(context)
+			}
+			return context.JSON(http.StatusOK, group)
+		}
+		apiVersion, rest = segments[1]+"/"+segments[2], segments[3:]
+	default:
+		return kubeNotFound(context)
+	}
+	if len(rest) == 0 {
+		list, ok := v1.GetAPIResourceList(apiVersion)
+		if !ok {
+			return kubeNotFound(context)
+		}
+		return context.JSON(http.StatusOK, list)
+	}
+	var namespace, resource, name string
+	if rest[0] == "namespaces" && len(rest) >= 3 {
+		namespace, rest = rest[1], rest[2:]
+	}
+	resource = rest[0]
+	if len(rest) == 2 {
+		name = rest[1]
+	} else if len(rest) > 2 {
+		return kubeNotFound(context)
+	}
+	descriptor, ok := v1.GetResourceDescriptorByResource(apiVersion, resource)
+	if !ok || (namespace != "" && !descriptor.Namespaced) {
+		return kubeNotFound(context)
+	}
+	if name != "" && namespace == "" && descriptor.Namespaced {
+		return kubeNotFound(context)
+	}
+	query := v1.ResourceQuery{
+		Type:          descriptor.Type,
+		CompanyId:     agent.CompanyId,
+		AgentName:     agent.Name,
+		Namespace:     namespace,
+		LabelSelector: context.QueryParam("labelSelector"),
+		FieldSelector: context.QueryParam("fieldSelector"),
+		// the agent belongs to the company, most objects it reports carry no company label
+		IncludeUnlabeled: true,
+	}
+	if watch := context.QueryParam("watch"); watch == "true" || watch == "1" {
+		return watchKubeObjects(context, query, name)
+	}
+	if name != "" {
+		return getKubeObject(context, descriptor, query, name)
+	}
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	list, err := v1.ListKubeObjects(query, limit, context.QueryParam("continue"))
+	if err != nil {
+		return kubeStatus(context, http.StatusBadRequest, v1.StatusReasonBadRequest, err.Error())
+	}
+	return context.JSON(http.StatusOK, list)
+}
+
+func getKubeObject(context echo.Context, descriptor v1.ResourceDescriptor, query v1.ResourceQuery, name string) error {
+	object, err := v1.FindResource(query, name)
+	if err == v1.ErrResourceNotFound {
+		return kubeStatus(context, http.StatusNotFound, v1.StatusReasonNotFound, descriptor.Resource+" \""+name+"\" not found")
+	} else if err != nil {
+		return kubeStatus(context, http.StatusBadRequest, v1.StatusReasonBadRequest, err.Error())
+	}
+	item, err := v1.ToKubeObject(descriptor, object.Obj)
+	if err != nil {
+		return kubeStatus(context, http.StatusInternalServerError, v1.StatusReasonInternalError, err.Error())
+	}
+	return context.JSONBlob(http.StatusOK, item)
+}
+
+// watchKubeObjects streams watch events as newline delimited json until the client disconnects
+// or timeoutSeconds elapses.
+func watchKubeObjects(context echo.Context, query v1.ResourceQuery, name string) error {
+	events, stop, err := v1.WatchKubeObjects(v1.KubeWatchRequest{
+		Type:          query.Type,
+		CompanyId:     query.CompanyId,
+		AgentName:     query.AgentName,
+		Namespace:     query.Namespace,
+		Name:          name,
+		LabelSelector: query.LabelSelector,
+		FieldSelector: query.FieldSelector,
+	})
+	if err != nil {
+		return kubeStatus(context, http.StatusBadRequest, v1.StatusReasonBadRequest, err.Error())
+	}
+	defer stop()
+	var timeout <-chan time.Time
+	if seconds, _ := strconv.ParseInt(context.QueryParam("timeoutSeconds"), 10, 64); seconds > 0 {
+		timer := time.NewTimer(time.Duration(seconds) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response.Header().Set("Transfer-Encoding", "chunked")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+	encoder := json.NewEncoder(response)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := encoder.Encode(event); err != nil {
+				return nil
+			}
+			response.Flush()
+		case <-timeout:
+			return nil
+		case <-context.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+// getKubernetesVersion serves /version from kubernetes version reported by the agent.
+func getKubernetesVersion(context echo.Context, agent v1.Agent) error {
+	version := map[string]string{"gitVersion": agent.KubernetesVersion}
+	parts := strings.SplitN(strings.TrimPrefix(agent.KubernetesVersion, "v"), ".", 3)
+	if len(parts) >= 2 {
+		version["major"] = parts[0]
+		version["minor"] = parts[1]
+	}
+	return context.JSON(http.StatusOK, version)
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"strconv"
+)
+
+func Pipelines(g *echo.Group) {
+	g.GET("/objects", GetPipelineObjects)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for listing objects a pipeline run, application or repository deployed, across agents and kinds
+// @Tags Pipelines
+// @Produce json
+// @Param company query string false "Company id"
+// @Param repository query string false "Repository id"
+// @Param application query string false "Application id"
+// @Param process_id query string false "Pipeline process id"
+// @Param agent query string false "Agent name"
+// @Param kind query string false "Resource type, e.g. deployment, service, configMap"
+// @Param include_deleted query bool false "Include deleted objects"
+// @Param page query int64 false "Page number, starts from 0"
+// @Param limit query int64 false "Page size"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.PipelineObject{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/pipelines/objects [GET]
+func GetPipelineObjects(context echo.Context) error {
+	page, _ := strconv.ParseInt(context.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	if limit <= 0 {
+		limit = v1.DefaultPageLimit
+	} else if limit > v1.MaxPageLimit {
+		limit = v1.MaxPageLimit
+	}
+	if page < 0 {
+		page = 0
+	}
+	includeDeleted, _ := strconv.ParseBool(context.QueryParam("include_deleted"))
+	objects, total, err := v1.FindPipelineObjects(v1.PipelineQuery{
+		CompanyId:      context.QueryParam("company"),
+		RepositoryId:   context.QueryParam("repository"),
+		ApplicationId:  context.QueryParam("application"),
+		ProcessId:      context.QueryParam("process_id"),
+		AgentName:      context.QueryParam("agent"),
+		Type:           enums.RESOURCE_TYPE(context.QueryParam("kind")),
+		IncludeDeleted: includeDeleted,
+		Page:           page,
+		Limit:          limit,
+	})
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	metadata := common.GetPaginationMetadata(page, limit, total, int64(len(objects)))
+	metadata.Links = common.GetPaginationLinks(context.Request().URL, page, limit, total)
+	return common.GenerateSuccessResponse(context, objects, &metadata, "Successfully Fetched!")
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"log"
+)
+
+func RedactionPolicies(g *echo.Group) {
+	g.GET("", GetRedactionPolicies)
+	g.POST("/dry_run", DryRunRedaction)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting active redaction rules
+// @Tags RedactionPolicies
+// @Produce json
+// @Success 200 {object} common.ResponseDTO{data=[]v1.RedactionRule{}}
+// @Router /api/v1/redaction_policies [GET]
+func GetRedactionPolicies(context echo.Context) error {
+	return common.GenerateSuccessResponse(context, v1.GetRedactionRules(), nil, "Successfully Fetched!")
+}
+
+// Post... Post Api
+// @Summary Post api
+// @Description Api for reporting fields of a kube event that would be redacted before persistence
+// @Tags RedactionPolicies
+// @Produce json
+// @Param data body v1.KubeEventMessage true "Kube event"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.RedactedField{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/redaction_policies/dry_run [POST]
+func DryRunRedaction(context echo.Context) error {
+	var kubeEvents v1.KubeEventMessage
+	if err := context.Bind(&kubeEvents); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	obj := kubeEvents.Body
+	if kubeEvents.Header.Command == enums.UPDATE {
+		if body, ok := kubeEvents.Body.(map[string]interface{}); ok {
+			obj = body["new_k8s_obj"]
+		}
+	}
+	report := v1.RedactionDryRun(enums.RESOURCE_TYPE(kubeEvents.Header.Extras["object"]), &obj)
+	return common.GenerateSuccessResponse(context, report, nil, "Successfully Evaluated!")
+}
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"strconv"
+)
+
+func Resources(g *echo.Group) {
+	g.GET("/:kind", GetResources)
+	g.GET("/:kind/:name", GetResource)
+}
+
+func getResourceQuery(context echo.Context) v1.ResourceQuery {
+	page, _ := strconv.ParseInt(context.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	if limit <= 0 {
+		limit = v1.DefaultPageLimit
+	} else if limit > v1.MaxPageLimit {
+		limit = v1.MaxPageLimit
+	}
+	if page < 0 {
+		page = 0
+	}
+	includeDeleted, _ := strconv.ParseBool(context.QueryParam("include_deleted"))
+	orphaned, _ := strconv.ParseBool(context.QueryParam("orphaned"))
+	return v1.ResourceQuery{
+		Type:           enums.RESOURCE_TYPE(context.Param("kind")),
+		CompanyId:      context.QueryParam("company"),
+		AgentName:      context.QueryParam("agent"),
+		Namespace:      context.QueryParam("namespace"),
+		LabelSelector:  context.QueryParam("labelSelector"),
+		FieldSelector:  context.QueryParam("fieldSelector"),
+		IncludeDeleted: includeDeleted,
+		Orphaned:       orphaned,
+		Page:           page,
+		Limit:          limit,
+	}
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for listing stored objects of a kind
+// @Tags Resources
+// @Produce json
+// @Param kind path string true "Resource type, e.g. pod, deployment, configMap"
+// @Param company query string false "Company id"
+// @Param agent query string false "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param include_deleted query bool false "Include tombstones of deleted objects"
+// @Param labelSelector query string false "Label selector, e.g. app=web,tier in (fe,be)"
+// @Param fieldSelector query string false "Field selector, e.g. status.phase=Running,spec.nodeName=n1"
+// @Param page query int64 false "Page number, starts from 0"
+// @Param limit query int64 false "Page size"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.StoredObject{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/resources/{kind} [GET]
+func GetResources(context echo.Context) error {
+	query := getResourceQuery(context)
+	objects, total, err := v1.FindResources(query)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	metadata := common.GetPaginationMetadata(query.Page, query.Limit, total, int64(len(objects)))
+	metadata.Links = common.GetPaginationLinks(context.Request().URL, query.Page, query.Limit, total)
+	return common.GenerateSuccessResponse(context, objects, &metadata, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting a stored object by name
+// @Tags Resources
+// @Produce json
+// @Param kind path string true "Resource type, e.g. pod, deployment, configMap"
+// @Param name path string true "Object name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param include_deleted query bool false "Include tombstones of deleted objects"
+// @Success 200 {object} common.ResponseDTO{data=v1.StoredObject{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/resources/{kind}/{name} [GET]
+func GetResource(context echo.Context) error {
+	query := getResourceQuery(context)
+	if query.AgentName == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	object, err := v1.FindResource(query, context.Param("name"))
+	if err == v1.ErrResourceNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, object, nil, "Successfully Fetched!")
+}
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"strconv"
+	"time"
+)
+
+func Revisions(g *echo.Group) {
+	g.GET("/namespaces/:namespace/as_of", GetNamespaceAsOf)
+	g.GET("/:kind/:name", GetRevisions)
+	g.GET("/:kind/:name/as_of", GetRevisionAsOf)
+}
+
+// parseTimeParam parses an optional RFC3339 query param.
+func parseTimeParam(context echo.Context, name string) (*time.Time, error) {
+	value := context.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for listing revisions of an object, latest first
+// @Tags Revisions
+// @Produce json
+// @Param kind path string true "Resource type, e.g. pod, deployment, configMap"
+// @Param name path string true "Object name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param from query string false "RFC3339 time, revisions recorded at or after"
+// @Param to query string false "RFC3339 time, revisions recorded at or before"
+// @Param page query int64 false "Page number, starts from 0"
+// @Param limit query int64 false "Page size"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.Revision{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/revisions/{kind}/{name} [GET]
+func GetRevisions(context echo.Context) error {
+	if context.QueryParam("agent") == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	from, err := parseTimeParam(context, "from")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid from time: "+err.Error())
+	}
+	to, err := parseTimeParam(context, "to")
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, "Invalid to time: "+err.Error())
+	}
+	page, _ := strconv.ParseInt(context.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	if limit <= 0 {
+		limit = v1.DefaultPageLimit
+	} else if limit > v1.MaxPageLimit {
+		limit = v1.MaxPageLimit
+	}
+	if page < 0 {
+		page = 0
+	}
+	query := v1.RevisionQuery{
+		Type:      enums.RESOURCE_TYPE(context.Param("kind")),
+		CompanyId: context.QueryParam("company"),
+		AgentName: context.QueryParam("agent"),
+		Namespace: context.QueryParam("namespace"),
+		Name:      context.Param("name"),
+		From:      from,
+		To:        to,
+		Page:      page,
+		Limit:     limit,
+	}
+	revisions, total, err := v1.FindRevisions(query)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	metadata := common.GetPaginationMetadata(page, limit, total, int64(len(revisions)))
+	metadata.Links = common.GetPaginationLinks(context.Request().URL, page, limit, total)
+	return common.GenerateSuccessResponse(context, revisions, &metadata, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting an object as it was at a given time
+// @Tags Revisions
+// @Produce json
+// @Param kind path string true "Resource type, e.g. pod, deployment, configMap"
+// @Param name path string true "Object name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace"
+// @Param time query string true "RFC3339 time"
+// @Success 200 {object} common.ResponseDTO{data=v1.Revision{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/revisions/{kind}/{name}/as_of [GET]
+func GetRevisionAsOf(context echo.Context) error {
+	if context.QueryParam("agent") == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	asOf, err := parseTimeParam(context, "time")
+	if err != nil || asOf == nil {
+		return common.GenerateErrorResponse(context, nil, "Valid RFC3339 time is required!")
+	}
+	query := v1.RevisionQuery{
+		Type:      enums.RESOURCE_TYPE(context.Param("kind")),
+		CompanyId: context.QueryParam("company"),
+		AgentName: context.QueryParam("agent"),
+		Namespace: context.QueryParam("namespace"),
+		Name:      context.Param("name"),
+	}
+	revision, err := v1.FindRevisionAsOf(query, *asOf)
+	if err == v1.ErrRevisionNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, revision, nil, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting every object of a namespace as it was at a given time
+// @Tags Revisions
+// @Produce json
+// @Param namespace path string true "Namespace"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param kind query string false "Resource type, all types if empty"
+// @Param time query string true "RFC3339 time"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.Revision{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/revisions/namespaces/{namespace}/as_of [GET]
+func GetNamespaceAsOf(context echo.Context) error {
+	if context.QueryParam("agent") == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	asOf, err := parseTimeParam(context, "time")
+	if err != nil || asOf == nil {
+		return common.GenerateErrorResponse(context, nil, "Valid RFC3339 time is required!")
+	}
+	revisions, err := v1.FindNamespaceAsOf(context.QueryParam("company"), context.QueryParam("agent"), context.Param("namespace"), enums.RESOURCE_TYPE(context.QueryParam("kind")), *asOf)
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, revisions, nil, "Successfully Fetched!")
+}
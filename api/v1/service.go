@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/labstack/echo/v4"
+)
+
+func Services(g *echo.Group) {
+	g.GET("/routing", GetServiceRouting)
+	g.GET("/:name/endpoints", GetServiceEndpoints)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for resolving a service to the pods its selector matches, along with resolved container ports
+// @Tags Services
+// @Produce json
+// @Param name path string true "Service name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string true "Namespace"
+// @Success 200 {object} common.ResponseDTO{data=v1.ServiceEndpoints{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/services/{name}/endpoints [GET]
+func GetServiceEndpoints(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	namespace := context.QueryParam("namespace")
+	if agent == "" || namespace == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent and namespace are required!")
+	}
+	endpoints, err := v1.ResolveServiceEndpoints(context.QueryParam("company"), agent, namespace, context.Param("name"))
+	if err == v1.ErrResourceNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, endpoints, nil, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for resolving every service of an agent to its pods, reporting services selecting no pod and pods no service selects
+// @Tags Services
+// @Produce json
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string false "Namespace, all namespaces if empty"
+// @Success 200 {object} common.ResponseDTO{data=v1.ServiceRoutingReport{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/services/routing [GET]
+func GetServiceRouting(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	if agent == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent is required!")
+	}
+	report, err := v1.AnalyzeServiceRouting(context.QueryParam("company"), agent, context.QueryParam("namespace"))
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, report, nil, "Successfully Fetched!")
+}
@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+	"log"
+	"strconv"
+)
+
+func Webhooks(g *echo.Group) {
+	g.POST("", CreateWebhook)
+	g.GET("", GetWebhooks)
+	g.GET("/:id", GetWebhook)
+	g.PUT("/:id", UpdateWebhook)
+	g.DELETE("/:id", DeleteWebhook)
+	g.GET("/:id/deliveries", GetWebhookDeliveries)
+}
+
+// Post... Post Api
+// @Summary Post api
+// @Description Api for subscribing a webhook to change events of stored objects in cloudevents 1.0 json format, filtered by company, agent, kinds and namespaces. Subscriptions are active unless active is false
+// @Tags Webhooks
+// @Produce json
+// @Param data body v1.Webhook true "Webhook"
+// @Success 200 {object} common.ResponseDTO{data=v1.Webhook{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/webhooks [POST]
+func CreateWebhook(context echo.Context) error {
+	var webhook v1.Webhook
+	if err := context.Bind(&webhook); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	created, err := webhook.Create()
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, created, nil, "Successfully Created!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting webhook subscriptions
+// @Tags Webhooks
+// @Produce json
+// @Param company query string true "Company id"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.Webhook{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/webhooks [GET]
+func GetWebhooks(context echo.Context) error {
+	company := context.QueryParam("company")
+	if company == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	return common.GenerateSuccessResponse(context, v1.FindWebhooks(company), nil, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting a webhook subscription
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook id"
+// @Param company query string true "Company id"
+// @Success 200 {object} common.ResponseDTO{data=v1.Webhook{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/webhooks/{id} [GET]
+func GetWebhook(context echo.Context) error {
+	company := context.QueryParam("company")
+	if company == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	webhook, err := v1.FindWebhook(company, context.Param("id"))
+	if err == v1.ErrWebhookNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, webhook, nil, "Successfully Fetched!")
+}
+
+// Put... Put Api
+// @Summary Put api
+// @Description Api for updating filters, url, secret and activeness of a webhook subscription, secret is kept if empty or masked and activeness if omitted
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook id"
+// @Param company query string true "Company id"
+// @Param data body v1.Webhook true "Webhook"
+// @Success 200 {object} common.ResponseDTO{data=v1.Webhook{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/webhooks/{id} [PUT]
+func UpdateWebhook(context echo.Context) error {
+	company := context.QueryParam("company")
+	if company == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	var webhook v1.Webhook
+	if err := context.Bind(&webhook); err != nil {
+		log.Println("Input Error:", err.Error())
+		return common.GenerateErrorResponse(context, nil, "Failed to Bind Input!")
+	}
+	webhook.Id = context.Param("id")
+	webhook.CompanyId = company
+	updated, err := webhook.Update()
+	if err == v1.ErrWebhookNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, updated, nil, "Successfully Updated!")
+}
+
+// Delete... Delete Api
+// @Summary Delete api
+// @Description Api for removing a webhook subscription
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook id"
+// @Param company query string true "Company id"
+// @Success 200 {object} common.ResponseDTO
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/webhooks/{id} [DELETE]
+func DeleteWebhook(context echo.Context) error {
+	company := context.QueryParam("company")
+	if company == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	err := v1.Webhook{Id: context.Param("id"), CompanyId: company}.Delete()
+	if err == v1.ErrWebhookNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, nil, nil, "Successfully Deleted!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting delivery log of a webhook subscription, latest first
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook id"
+// @Param company query string true "Company id"
+// @Param status query string false "PENDING, DELIVERED or FAILED"
+// @Param page query int64 false "Page number, starts from 0"
+// @Param limit query int64 false "Page size"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.WebhookDelivery{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Router /api/v1/webhooks/{id}/deliveries [GET]
+func GetWebhookDeliveries(context echo.Context) error {
+	company := context.QueryParam("company")
+	if company == "" {
+		return common.GenerateErrorResponse(context, nil, "Company is required!")
+	}
+	page, _ := strconv.ParseInt(context.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(context.QueryParam("limit"), 10, 64)
+	if limit <= 0 {
+		limit = v1.DefaultPageLimit
+	} else if limit > v1.MaxPageLimit {
+		limit = v1.MaxPageLimit
+	}
+	if page < 0 {
+		page = 0
+	}
+	deliveries, total, err := v1.FindWebhookDeliveries(v1.WebhookDeliveryQuery{
+		CompanyId: company,
+		WebhookId: context.Param("id"),
+		Status:    enums.WEBHOOK_DELIVERY_STATUS(context.QueryParam("status")),
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	metadata := common.GetPaginationMetadata(page, limit, total, int64(len(deliveries)))
+	metadata.Links = common.GetPaginationLinks(context.Request().URL, page, limit, total)
+	return common.GenerateSuccessResponse(context, deliveries, &metadata, "Successfully Fetched!")
+}
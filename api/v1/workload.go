@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/api/common"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"github.com/labstack/echo/v4"
+)
+
+func Workloads(g *echo.Group) {
+	g.GET("/:kind/:name/tree", GetOwnershipTree)
+	g.GET("/deployment/:name/rollouts", GetRolloutHistory)
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting ownership tree of a workload, e.g. deployment to its replicasets to their pods, with summarized status
+// @Tags Workloads
+// @Produce json
+// @Param kind path string true "Workload type, one of deployment, replicaset, statefulset, daemonset and pod"
+// @Param name path string true "Workload name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string true "Namespace"
+// @Success 200 {object} common.ResponseDTO{data=v1.OwnershipNode{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/workloads/{kind}/{name}/tree [GET]
+func GetOwnershipTree(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	namespace := context.QueryParam("namespace")
+	if agent == "" || namespace == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent and namespace are required!")
+	}
+	tree, err := v1.FindOwnershipTree(enums.RESOURCE_TYPE(context.Param("kind")), context.QueryParam("company"), agent, namespace, context.Param("name"))
+	if err == v1.ErrResourceNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, tree, nil, "Successfully Fetched!")
+}
+
+// Get... Get Api
+// @Summary Get api
+// @Description Api for getting rollout history of a deployment, reconstructed from recorded replicasets, including garbage collected ones
+// @Tags Workloads
+// @Produce json
+// @Param name path string true "Deployment name"
+// @Param company query string false "Company id"
+// @Param agent query string true "Agent name"
+// @Param namespace query string true "Namespace"
+// @Success 200 {object} common.ResponseDTO{data=[]v1.RolloutRevision{}}
+// @Failure 400 {object} common.ResponseDTO
+// @Failure 404 {object} common.ResponseDTO
+// @Router /api/v1/workloads/deployment/{name}/rollouts [GET]
+func GetRolloutHistory(context echo.Context) error {
+	agent := context.QueryParam("agent")
+	namespace := context.QueryParam("namespace")
+	if agent == "" || namespace == "" {
+		return common.GenerateErrorResponse(context, nil, "Agent and namespace are required!")
+	}
+	history, err := v1.FindRolloutHistory(context.QueryParam("company"), agent, namespace, context.Param("name"))
+	if err == v1.ErrResourceNotFound {
+		return common.GenerateNotFoundResponse(context, nil, err.Error())
+	} else if err != nil {
+		return common.GenerateErrorResponse(context, nil, err.Error())
+	}
+	return common.GenerateSuccessResponse(context, history, nil, "Successfully Fetched!")
+}
@@ -5,6 +5,9 @@ import (
 	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // ServerPort refers to server port.
@@ -34,6 +37,91 @@ var Database string
 // RunMode refers to run mode.
 var RunMode string
 
+// RedactionPolicyFile refers to json file of redaction rules, default rules are used if empty.
+var RedactionPolicyFile string
+
+// AgentRateLimit refers to allowed kube events per second per agent, 0 disables the limit.
+var AgentRateLimit float64
+
+// AgentRateLimitBurst refers to maximum burst of kube events per agent.
+var AgentRateLimitBurst int
+
+// CompanyRateLimit refers to allowed kube events per second per company, 0 disables the limit.
+var CompanyRateLimit float64
+
+// CompanyRateLimitBurst refers to maximum burst of kube events per company.
+var CompanyRateLimitBurst int
+
+// MaxObjectsPerKind refers to maximum stored objects per kind per company, 0 disables the quota.
+var MaxObjectsPerKind int64
+
+// MaxObjectsPerKindOverrides refers to per kind overrides of MaxObjectsPerKind.
+var MaxObjectsPerKindOverrides map[string]int64
+
+// AgentHeartbeatTimeout refers to silence after which an agent is not considered alive.
+var AgentHeartbeatTimeout time.Duration
+
+// AgentStaleAfter refers to silence after which an agent is marked stale and its objects unverified.
+var AgentStaleAfter time.Duration
+
+// AgentPurgeAfter refers to silence after which objects of a stale agent are removed according to AgentPurgePolicy.
+var AgentPurgeAfter time.Duration
+
+// AgentPurgePolicy refers to policy of removing objects of a stale agent, one of RETAIN, ARCHIVE and PURGE.
+var AgentPurgePolicy string
+
+// StaleAgentCheckInterval refers to interval of stale agent detection.
+var StaleAgentCheckInterval time.Duration
+
+// EventRetention refers to how long kube events are kept after their last occurrence.
+var EventRetention time.Duration
+
+// EventRetentionOverrides refers to per company overrides of EventRetention.
+var EventRetentionOverrides map[string]time.Duration
+
+// TombstoneRetention refers to how long deleted objects are kept as tombstones, 0 keeps them forever.
+var TombstoneRetention time.Duration
+
+// TombstonePurgeInterval refers to interval of removing expired tombstones.
+var TombstonePurgeInterval time.Duration
+
+// RevisionRetention refers to how long revisions of objects are kept, 0 keeps them forever. Revisions of kube events
+// are kept as long as the events.
+var RevisionRetention time.Duration
+
+// OwnerGCInterval refers to interval of detecting dependents whose owners no longer exist, 0 disables detection.
+var OwnerGCInterval time.Duration
+
+// OwnerGCGracePeriod refers to how long a dependent stays orphaned before it is deleted.
+var OwnerGCGracePeriod time.Duration
+
+// OwnerGCPolicy refers to policy of handling orphaned dependents, one of FLAG and DELETE, FLAG by default.
+var OwnerGCPolicy string
+
+// RolloutNotificationUrl refers to url rollout results of workloads stamped by the pipeline are posted to, empty disables notifications.
+var RolloutNotificationUrl string
+
+// PipelineProcessIdKey refers to label or annotation key the pipeline stamps its process id with.
+var PipelineProcessIdKey string
+
+// PipelineRepositoryKey refers to label or annotation key the pipeline stamps its repository id with.
+var PipelineRepositoryKey string
+
+// PipelineApplicationKey refers to label or annotation key the pipeline stamps its application id with.
+var PipelineApplicationKey string
+
+// WebhookTimeout refers to timeout of a webhook delivery attempt.
+var WebhookTimeout time.Duration
+
+// WebhookMaxAttempts refers to how many times a change event is posted to a webhook before it is given up.
+var WebhookMaxAttempts int64
+
+// WebhookRetryBackoff refers to wait before the first retry of a webhook delivery, doubled for every next retry.
+var WebhookRetryBackoff time.Duration
+
+// WebhookDeliveryRetention refers to how long webhook delivery log is kept.
+var WebhookDeliveryRetention time.Duration
+
 // InitEnvironmentVariables initializes environment variables
 func InitEnvironmentVariables() {
 	RunMode = os.Getenv("RUN_MODE")
@@ -58,7 +146,142 @@ func InitEnvironmentVariables() {
 	DbPassword = os.Getenv("MONGO_PASSWORD")
 	DatabaseName = os.Getenv("DATABASE_NAME")
 	Database = os.Getenv("DATABASE")
+	RedactionPolicyFile = os.Getenv("REDACTION_POLICY_FILE")
+	AgentRateLimit = getFloatEnv("AGENT_RATE_LIMIT", 0)
+	AgentRateLimitBurst = int(getIntEnv("AGENT_RATE_LIMIT_BURST", 0))
+	CompanyRateLimit = getFloatEnv("COMPANY_RATE_LIMIT", 0)
+	CompanyRateLimitBurst = int(getIntEnv("COMPANY_RATE_LIMIT_BURST", 0))
+	MaxObjectsPerKind = getIntEnv("MAX_OBJECTS_PER_KIND", 0)
+	MaxObjectsPerKindOverrides = getIntMapEnv("MAX_OBJECTS_PER_KIND_OVERRIDES")
+	AgentHeartbeatTimeout = getDurationEnv("AGENT_HEARTBEAT_TIMEOUT", 5*time.Minute)
+	AgentStaleAfter = getDurationEnv("AGENT_STALE_AFTER", 30*time.Minute)
+	AgentPurgeAfter = getDurationEnv("AGENT_PURGE_AFTER", 7*24*time.Hour)
+	AgentPurgePolicy = os.Getenv("AGENT_PURGE_POLICY")
+	switch enums.RETENTION_POLICY(AgentPurgePolicy) {
+	case "":
+		AgentPurgePolicy = string(enums.ARCHIVE)
+	case enums.RETAIN, enums.ARCHIVE, enums.PURGE:
+	default:
+		log.Fatalln("ERROR: invalid value of AGENT_PURGE_POLICY:", AgentPurgePolicy+", must be RETAIN, ARCHIVE or PURGE")
+	}
+	StaleAgentCheckInterval = getDurationEnv("STALE_AGENT_CHECK_INTERVAL", time.Minute)
+	EventRetention = getDurationEnv("EVENT_RETENTION", 7*24*time.Hour)
+	EventRetentionOverrides = getDurationMapEnv("EVENT_RETENTION_OVERRIDES")
+	TombstoneRetention = getDurationEnv("TOMBSTONE_RETENTION", 7*24*time.Hour)
+	TombstonePurgeInterval = getDurationEnv("TOMBSTONE_PURGE_INTERVAL", time.Hour)
+	RevisionRetention = getDurationEnv("REVISION_RETENTION", 30*24*time.Hour)
+	OwnerGCInterval = getDurationEnv("OWNER_GC_INTERVAL", 5*time.Minute)
+	OwnerGCGracePeriod = getDurationEnv("OWNER_GC_GRACE_PERIOD", 10*time.Minute)
+	OwnerGCPolicy = os.Getenv("OWNER_GC_POLICY")
+	switch enums.ORPHAN_POLICY(OwnerGCPolicy) {
+	case "":
+		OwnerGCPolicy = string(enums.ORPHAN_FLAG)
+	case enums.ORPHAN_FLAG, enums.ORPHAN_DELETE:
+	default:
+		log.Fatalln("ERROR: invalid value of OWNER_GC_POLICY:", OwnerGCPolicy+", must be FLAG or DELETE")
+	}
+	RolloutNotificationUrl = os.Getenv("ROLLOUT_NOTIFICATION_URL")
+	PipelineProcessIdKey = os.Getenv("PIPELINE_PROCESS_ID_KEY")
+	if PipelineProcessIdKey == "" {
+		PipelineProcessIdKey = "processId"
+	}
+	PipelineRepositoryKey = os.Getenv("PIPELINE_REPOSITORY_KEY")
+	if PipelineRepositoryKey == "" {
+		PipelineRepositoryKey = "repositoryId"
+	}
+	PipelineApplicationKey = os.Getenv("PIPELINE_APPLICATION_KEY")
+	if PipelineApplicationKey == "" {
+		PipelineApplicationKey = "applicationId"
+	}
+	WebhookTimeout = getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second)
+	WebhookMaxAttempts = getIntEnv("WEBHOOK_MAX_ATTEMPTS", 5)
+	WebhookRetryBackoff = getDurationEnv("WEBHOOK_RETRY_BACKOFF", time.Second)
+	WebhookDeliveryRetention = getDurationEnv("WEBHOOK_DELIVERY_RETENTION", 7*24*time.Hour)
 	if Database == enums.MONGO {
 		DatabaseConnectionString = "mongodb://" + DbUsername + ":" + DbPassword + "@" + DbServer + ":" + DbPort
 	}
 }
+
+func getIntEnv(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Println("ERROR: invalid value of", key+":", err.Error())
+		return defaultValue
+	}
+	return parsed
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Println("ERROR: invalid value of", key+":", err.Error())
+		return defaultValue
+	}
+	return parsed
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Println("ERROR: invalid value of", key+":", err.Error())
+		return defaultValue
+	}
+	return parsed
+}
+
+// getMapEnv parses comma separated key=value pairs, e.g. pod=1000,event=5000
+func getMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			log.Println("ERROR: invalid entry of", key+":", pair)
+			continue
+		}
+		result[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return result
+}
+
+// getIntMapEnv parses comma separated key=value pairs of int values, e.g. pod=1000,event=5000
+func getIntMapEnv(key string) map[string]int64 {
+	result := make(map[string]int64)
+	for k, v := range getMapEnv(key) {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Println("ERROR: invalid entry of", key+":", k+"="+v)
+			continue
+		}
+		result[k] = parsed
+	}
+	return result
+}
+
+// getDurationMapEnv parses comma separated key=value pairs of duration values, e.g. company1=24h,company2=720h
+func getDurationMapEnv(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for k, v := range getMapEnv(key) {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			log.Println("ERROR: invalid entry of", key+":", k+"="+v)
+			continue
+		}
+		result[k] = parsed
+	}
+	return result
+}
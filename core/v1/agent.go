@@ -0,0 +1,312 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AgentCollection = "agentCollection"
+
+// ErrAgentNotFound returned when an agent is not registered.
+var ErrAgentNotFound = errors.New("agent not found")
+
+// AgentCounters number of kube events received from an agent per command.
+type AgentCounters struct {
+	Add    int64 `json:"add" bson:"add"`
+	Update int64 `json:"update" bson:"update"`
+	Delete int64 `json:"delete" bson:"delete"`
+}
+
+// Agent registered light house agent.
+type Agent struct {
+	CompanyId         string             `json:"company" bson:"company"`
+	Name              string             `json:"agent_name" bson:"agent_name"`
+	Version           string             `json:"version" bson:"version"`
+	KubernetesVersion string             `json:"kubernetes_version" bson:"kubernetes_version"`
+	Labels            map[string]string  `json:"labels" bson:"labels"`
+	FirstSeen         time.Time          `json:"first_seen" bson:"first_seen"`
+	LastSeen          time.Time          `json:"last_seen" bson:"last_seen"`
+	LastHeartbeat     time.Time          `json:"last_heartbeat" bson:"last_heartbeat"`
+	Counters          AgentCounters      `json:"counters" bson:"counters"`
+	Status            enums.AGENT_STATUS `json:"status" bson:"status"`
+	StatusChangedAt   time.Time          `json:"status_changed_at" bson:"status_changed_at"`
+	Alive             bool               `json:"alive" bson:"-"`
+}
+
+// AgentHeartbeat heartbeat sent by an agent.
+type AgentHeartbeat struct {
+	CompanyId         string            `json:"company"`
+	Version           string            `json:"version"`
+	KubernetesVersion string            `json:"kubernetes_version"`
+	Labels            map[string]string `json:"labels"`
+}
+
+func agentFilter(companyId, name string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": name},
+			{"company": companyId},
+		},
+	}
+}
+
+func (a Agent) withStatus(now time.Time) Agent {
+	a.Alive = !a.LastSeen.IsZero() && now.Sub(a.LastSeen) <= config.AgentHeartbeatTimeout
+	return a
+}
+
+func (a Agent) upsert(set bson.M, inc bson.M) (Agent, error) {
+	now := time.Now().UTC()
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"first_seen": now, "status": enums.AGENT_ACTIVE, "status_changed_at": now},
+	}
+	if inc != nil {
+		update["$inc"] = inc
+	}
+	upsert := true
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+		Upsert:         &upsert,
+	}
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	result := coll.FindOneAndUpdate(db.GetDmManager().Ctx, agentFilter(a.CompanyId, a.Name), update, &opt)
+	if result.Err() != nil {
+		log.Println("[ERROR]", result.Err())
+		return Agent{}, result.Err()
+	}
+	var agent Agent
+	if err := result.Decode(&agent); err != nil {
+		log.Println("[ERROR]", err)
+		return Agent{}, err
+	}
+	return agent.withStatus(now), nil
+}
+
+// agentCompanyRefreshInterval refers to how often companies of registered agents are reloaded.
+const agentCompanyRefreshInterval = time.Minute
+
+// agentCompanyCache companies of registered agent names, so kube events of objects carrying no company label are
+// attributed to the company of their agent without querying the registry every time.
+type agentCompanyCache struct {
+	mu        sync.Mutex
+	companies map[string]string
+	loadedAt  time.Time
+}
+
+var singletonAgentCompanyCache *agentCompanyCache
+var onceAgentCompanyCache sync.Once
+
+func getAgentCompanyCache() *agentCompanyCache {
+	onceAgentCompanyCache.Do(func() {
+		singletonAgentCompanyCache = &agentCompanyCache{}
+	})
+	return singletonAgentCompanyCache
+}
+
+func (c *agentCompanyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
+
+// AgentCompany returns company of a registered agent. Empty if the agent is not registered with a company or its name
+// is registered by more than one company.
+func AgentCompany(agent string) string {
+	c := getAgentCompanyCache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.loadedAt) > agentCompanyRefreshInterval {
+		c.companies = make(map[string]string)
+		ambiguous := make(map[string]bool)
+		for _, each := range FindAgents("") {
+			if each.CompanyId == "" {
+				continue
+			}
+			if company, ok := c.companies[each.Name]; ok && company != each.CompanyId {
+				ambiguous[each.Name] = true
+			}
+			c.companies[each.Name] = each.CompanyId
+		}
+		for name := range ambiguous {
+			delete(c.companies, name)
+		}
+		c.loadedAt = time.Now()
+	}
+	return c.companies[agent]
+}
+
+// Register registers or re-registers the agent. An entry recorded for the agent before its company was known is
+// replaced by the registered one.
+func (a Agent) Register() (Agent, error) {
+	if a.CompanyId == "" || a.Name == "" {
+		return Agent{}, errors.New("company and agent name are required")
+	}
+	now := time.Now().UTC()
+	set := bson.M{
+		"company":        a.CompanyId,
+		"agent_name":     a.Name,
+		"last_seen":      now,
+		"last_heartbeat": now,
+	}
+	if a.Version != "" {
+		set["version"] = a.Version
+	}
+	if a.KubernetesVersion != "" {
+		set["kubernetes_version"] = a.KubernetesVersion
+	}
+	if a.Labels != nil {
+		set["labels"] = a.Labels
+	}
+	agent, err := a.upsert(set, nil)
+	if err != nil {
+		return Agent{}, err
+	}
+	_, err = db.GetDmManager().Db.Collection(AgentCollection).DeleteOne(db.GetDmManager().Ctx, agentFilter("", a.Name))
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+	getAgentCompanyCache().invalidate()
+	return agent, nil
+}
+
+// Heartbeat records a heartbeat of the agent.
+func (a Agent) Heartbeat(heartbeat AgentHeartbeat) (Agent, error) {
+	now := time.Now().UTC()
+	set := bson.M{
+		"company":        a.CompanyId,
+		"agent_name":     a.Name,
+		"last_seen":      now,
+		"last_heartbeat": now,
+	}
+	if heartbeat.Version != "" {
+		set["version"] = heartbeat.Version
+	}
+	if heartbeat.KubernetesVersion != "" {
+		set["kubernetes_version"] = heartbeat.KubernetesVersion
+	}
+	if heartbeat.Labels != nil {
+		set["labels"] = heartbeat.Labels
+	}
+	return a.upsert(set, nil)
+}
+
+// RecordActivity marks the agent as seen and counts the received kube event. An agent whose company is not known yet
+// is recorded without a company, so stale detection and owner collection still cover it.
+func (a Agent) RecordActivity(command enums.Command) {
+	if a.Name == "" {
+		return
+	}
+	set := bson.M{
+		"company":    a.CompanyId,
+		"agent_name": a.Name,
+		"last_seen":  time.Now().UTC(),
+	}
+	var inc bson.M
+	if command != "" {
+		inc = bson.M{"counters." + strings.ToLower(string(command)): 1}
+	}
+	a.upsert(set, inc)
+}
+
+// Update updates version and cluster labels of a registered agent. Omitted fields are kept.
+func (a Agent) Update() (Agent, error) {
+	set := bson.M{}
+	if a.Version != "" {
+		set["version"] = a.Version
+	}
+	if a.KubernetesVersion != "" {
+		set["kubernetes_version"] = a.KubernetesVersion
+	}
+	if a.Labels != nil {
+		set["labels"] = a.Labels
+	}
+	if len(set) == 0 {
+		return FindAgent(a.CompanyId, a.Name)
+	}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	result := coll.FindOneAndUpdate(db.GetDmManager().Ctx, agentFilter(a.CompanyId, a.Name), bson.M{"$set": set}, &opt)
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return Agent{}, ErrAgentNotFound
+		}
+		log.Println("[ERROR]", result.Err())
+		return Agent{}, result.Err()
+	}
+	var agent Agent
+	if err := result.Decode(&agent); err != nil {
+		return Agent{}, err
+	}
+	return agent.withStatus(time.Now()), nil
+}
+
+// Delete removes the agent from registry.
+func (a Agent) Delete() error {
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	result, err := coll.DeleteOne(db.GetDmManager().Ctx, agentFilter(a.CompanyId, a.Name))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrAgentNotFound
+	}
+	getAgentCompanyCache().invalidate()
+	return nil
+}
+
+// FindAgent returns a registered agent.
+func FindAgent(companyId, name string) (Agent, error) {
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	result := coll.FindOne(db.GetDmManager().Ctx, agentFilter(companyId, name))
+	var agent Agent
+	if err := result.Decode(&agent); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Agent{}, ErrAgentNotFound
+		}
+		log.Println("[ERROR]", err)
+		return Agent{}, err
+	}
+	return agent.withStatus(time.Now()), nil
+}
+
+// FindAgents returns registered agents of a company, all agents if companyId is empty.
+func FindAgents(companyId string) []Agent {
+	query := bson.M{}
+	if companyId != "" {
+		query["company"] = companyId
+	}
+	agents := []Agent{}
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	curser, err := coll.Find(db.GetDmManager().Ctx, query, options.Find().SetSort(bson.M{"agent_name": 1}))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return agents
+	}
+	now := time.Now()
+	for curser.Next(context.TODO()) {
+		elemValue := new(Agent)
+		err := curser.Decode(elemValue)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		agents = append(agents, elemValue.withStatus(now))
+	}
+	return agents
+}
@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"time"
+)
+
+const AgentDecommissionCollection = "agentDecommissionCollection"
+
+// ErrDecommissionNotFound returned when a decommission job does not exist.
+var ErrDecommissionNotFound = errors.New("decommission not found")
+
+// DecommissionProgress progress of a collection in a decommission job.
+type DecommissionProgress struct {
+	Collection string `json:"collection" bson:"collection"`
+	Total      int64  `json:"total" bson:"total"`
+	Removed    int64  `json:"removed" bson:"removed"`
+	Done       bool   `json:"done" bson:"done"`
+}
+
+// AgentDecommission job removing every stored document of an agent.
+type AgentDecommission struct {
+	Id         string                 `json:"id" bson:"id"`
+	CompanyId  string                 `json:"company" bson:"company"`
+	AgentName  string                 `json:"agent_name" bson:"agent_name"`
+	Policy     enums.RETENTION_POLICY `json:"policy" bson:"policy"`
+	DryRun     bool                   `json:"dry_run" bson:"dry_run"`
+	Status     enums.JOB_STATUS       `json:"status" bson:"status"`
+	Total      int64                  `json:"total" bson:"total"`
+	Removed    int64                  `json:"removed" bson:"removed"`
+	Progress   []DecommissionProgress `json:"progress" bson:"progress"`
+	Error      string                 `json:"error,omitempty" bson:"error"`
+	StartedAt  time.Time              `json:"started_at" bson:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty" bson:"finished_at"`
+}
+
+// agentScopedCollections collections other than resource collections, keyed by company and agent_name,
+// holding documents of an agent.
+var agentScopedCollections = []string{AgentIndexCollection, RevisionCollection, PipelineIndexCollection}
+
+// agentIndexQuery matches documents of an agent of a company, including those of objects carrying no company label.
+func agentIndexQuery(companyId, agent string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": agent},
+			{"company": bson.M{"$in": []interface{}{companyId, nil, ""}}},
+		},
+	}
+}
+
+// countAgentDocuments returns per collection count of documents of the agent.
+func countAgentDocuments(companyId, agent string) []DecommissionProgress {
+	progress := []DecommissionProgress{}
+	for _, each := range Resources {
+		count, err := db.GetDmManager().Db.Collection(each.Collection).CountDocuments(db.GetDmManager().Ctx, agentObjectsQuery(companyId, agent))
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+		progress = append(progress, DecommissionProgress{Collection: each.Collection, Total: count})
+	}
+	for _, each := range agentScopedCollections {
+		count, err := db.GetDmManager().Db.Collection(each).CountDocuments(db.GetDmManager().Ctx, agentIndexQuery(companyId, agent))
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+		progress = append(progress, DecommissionProgress{Collection: each, Total: count})
+	}
+	return progress
+}
+
+// DecommissionAgent removes every document of an agent across resource collections, agent and pipeline indexes and revision history, according to
+// the policy. In dry run only document counts are reported, otherwise removal runs in background and its progress
+// can be fetched by FindAgentDecommission.
+func DecommissionAgent(companyId, agent string, policy enums.RETENTION_POLICY, dryRun bool) (AgentDecommission, error) {
+	if companyId == "" || agent == "" {
+		return AgentDecommission{}, errors.New("company and agent name are required")
+	}
+	if policy == "" {
+		policy = enums.PURGE
+	}
+	if policy != enums.PURGE && policy != enums.ARCHIVE {
+		return AgentDecommission{}, errors.New("policy must be PURGE or ARCHIVE")
+	}
+	job := AgentDecommission{
+		Id:        primitive.NewObjectID().Hex(),
+		CompanyId: companyId,
+		AgentName: agent,
+		Policy:    policy,
+		DryRun:    dryRun,
+		Status:    enums.JOB_RUNNING,
+		Progress:  countAgentDocuments(companyId, agent),
+		StartedAt: time.Now().UTC(),
+	}
+	for _, each := range job.Progress {
+		job.Total += each.Total
+	}
+	if dryRun {
+		job.Status = enums.JOB_COMPLETED
+		job.FinishedAt = &job.StartedAt
+		return job, nil
+	}
+	_, err := db.GetDmManager().Db.Collection(AgentDecommissionCollection).InsertOne(db.GetDmManager().Ctx, job)
+	if err != nil {
+		log.Println("[ERROR] Insert document:", err.Error())
+		return AgentDecommission{}, err
+	}
+	go job.run()
+	return job, nil
+}
+
+func (job AgentDecommission) run() {
+	reason := "agent decommissioned"
+	agent := Agent{CompanyId: job.CompanyId, Name: job.AgentName}
+	_, err := agent.removeObjects(job.Policy, reason, func(collection string, removed int64) {
+		for i := range job.Progress {
+			if job.Progress[i].Collection == collection {
+				job.Progress[i].Removed = removed
+				job.Progress[i].Done = true
+				break
+			}
+		}
+		job.Removed += removed
+		job.save()
+	})
+	for _, each := range agentScopedCollections {
+		if err != nil {
+			break
+		}
+		var removed int64
+		removed, err = removeObjects(each, agentIndexQuery(job.CompanyId, job.AgentName), job.Policy, reason)
+		for i := range job.Progress {
+			if job.Progress[i].Collection == each {
+				job.Progress[i].Removed = removed
+				job.Progress[i].Done = err == nil
+				break
+			}
+		}
+		job.Removed += removed
+		job.save()
+	}
+	if err == nil {
+		if deleteErr := agent.Delete(); deleteErr != nil && deleteErr != ErrAgentNotFound {
+			err = deleteErr
+		}
+	}
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	job.Status = enums.JOB_COMPLETED
+	if err != nil {
+		job.Status = enums.JOB_FAILED
+		job.Error = err.Error()
+		log.Println("[ERROR] Failed to decommission agent", job.AgentName+":", err.Error())
+	} else {
+		log.Println("[INFO] Decommissioned agent", job.AgentName, "of company", job.CompanyId+", removed", job.Removed, "documents")
+	}
+	job.save()
+}
+
+func (job AgentDecommission) save() {
+	coll := db.GetDmManager().Db.Collection(AgentDecommissionCollection)
+	_, err := coll.ReplaceOne(db.GetDmManager().Ctx, bson.M{"id": job.Id}, job)
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+}
+
+// FindAgentDecommission returns a decommission job.
+func FindAgentDecommission(id string) (AgentDecommission, error) {
+	coll := db.GetDmManager().Db.Collection(AgentDecommissionCollection)
+	var job AgentDecommission
+	if err := coll.FindOne(db.GetDmManager().Ctx, bson.M{"id": id}).Decode(&job); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return AgentDecommission{}, ErrDecommissionNotFound
+		}
+		log.Println("[ERROR]", err)
+		return AgentDecommission{}, err
+	}
+	return job, nil
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"context"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"time"
+)
+
+const ArchivedObjectCollection = "archivedObjectCollection"
+
+const archiveBatchSize = 500
+
+// ArchivedObject stored object moved out of its resource collection.
+type ArchivedObject struct {
+	Collection string    `json:"collection" bson:"collection"`
+	Reason     string    `json:"reason" bson:"reason"`
+	ArchivedAt time.Time `json:"archived_at" bson:"archived_at"`
+	Document   bson.M    `json:"document" bson:"document"`
+}
+
+// removeObjects archives or purges documents of a collection matching the query, according to the policy.
+// Returns number of removed documents.
+func removeObjects(collection string, query bson.M, policy enums.RETENTION_POLICY, reason string) (int64, error) {
+	coll := db.GetDmManager().Db.Collection(collection)
+	if policy == enums.RETAIN {
+		return 0, nil
+	}
+	if policy == enums.PURGE {
+		result, err := coll.DeleteMany(db.GetDmManager().Ctx, query)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			return 0, err
+		}
+		return result.DeletedCount, nil
+	}
+	curser, err := coll.Find(db.GetDmManager().Ctx, query)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return 0, err
+	}
+	defer curser.Close(context.TODO())
+	var removed int64
+	var archived []interface{}
+	var ids []interface{}
+	flush := func() error {
+		if len(archived) == 0 {
+			return nil
+		}
+		if _, err := db.GetDmManager().Db.Collection(ArchivedObjectCollection).InsertMany(db.GetDmManager().Ctx, archived); err != nil {
+			log.Println("[ERROR] Insert document:", err.Error())
+			return err
+		}
+		result, err := coll.DeleteMany(db.GetDmManager().Ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			log.Println("[ERROR]", err)
+			return err
+		}
+		removed += result.DeletedCount
+		archived = nil
+		ids = nil
+		return nil
+	}
+	now := time.Now().UTC()
+	for curser.Next(context.TODO()) {
+		var document bson.M
+		if err := curser.Decode(&document); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		archived = append(archived, ArchivedObject{
+			Collection: collection,
+			Reason:     reason,
+			ArchivedAt: now,
+			Document:   document,
+		})
+		ids = append(ids, document["_id"])
+		if len(archived) >= archiveBatchSize {
+			if err := flush(); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, flush()
+}
@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	AuditLogCollection  = "auditLogCollection"
+	AuditHeadCollection = "auditHeadCollection"
+)
+
+// auditAppendRetries number of attempts to append an entry when another instance appends concurrently.
+const auditAppendRetries = 5
+
+const (
+	// AuditSuccess command applied.
+	AuditSuccess = "SUCCESS"
+	// AuditRejected command rejected by rate limit or quota.
+	AuditRejected = "REJECTED"
+	// AuditFailed command failed.
+	AuditFailed = "FAILED"
+)
+
+// AuditEntry received ingestion command. Entries of an agent form a hash chain, each entry's hash covers
+// its content and the hash of the previous entry.
+type AuditEntry struct {
+	Id         string              `json:"id" bson:"_id"`
+	CompanyId  string              `json:"company" bson:"company"`
+	AgentName  string              `json:"agent_name" bson:"agent_name"`
+	Sequence   int64               `json:"sequence" bson:"sequence"`
+	ReceivedAt time.Time           `json:"received_at" bson:"received_at"`
+	RemoteAddr string              `json:"remote_addr" bson:"remote_addr"`
+	UserAgent  string              `json:"user_agent" bson:"user_agent"`
+	Command    enums.Command       `json:"command" bson:"command"`
+	Offset     int                 `json:"offset" bson:"offset"`
+	Kind       enums.RESOURCE_TYPE `json:"kind" bson:"kind"`
+	Namespace  string              `json:"namespace" bson:"namespace"`
+	Name       string              `json:"name" bson:"name"`
+	UID        string              `json:"uid" bson:"uid"`
+	Result     string              `json:"result" bson:"result"`
+	StatusCode int                 `json:"status_code" bson:"status_code"`
+	PrevHash   string              `json:"prev_hash" bson:"prev_hash"`
+	Hash       string              `json:"hash" bson:"hash"`
+}
+
+// auditHead latest entry of an agent's chain, used to detect removal of entries from the end of the chain.
+type auditHead struct {
+	CompanyId string `bson:"company"`
+	AgentName string `bson:"agent_name"`
+	Sequence  int64  `bson:"sequence"`
+	Hash      string `bson:"hash"`
+}
+
+// AuditVerification result of verifying an agent's chain.
+type AuditVerification struct {
+	CompanyId       string `json:"company"`
+	AgentName       string `json:"agent_name"`
+	Valid           bool   `json:"valid"`
+	EntriesChecked  int64  `json:"entries_checked"`
+	InvalidSequence int64  `json:"invalid_sequence,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// AuditResult returns audit result of a response status code.
+func AuditResult(statusCode int) string {
+	switch statusCode {
+	case http.StatusOK:
+		return AuditSuccess
+	case http.StatusTooManyRequests:
+		return AuditRejected
+	}
+	return AuditFailed
+}
+
+// computeHash returns hash of the entry content chained to its previous hash.
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	e.ReceivedAt = e.ReceivedAt.UTC()
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func auditChainFilter(companyId, agent string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": agent},
+			{"company": companyId},
+		},
+	}
+}
+
+var auditLocks sync.Map
+
+// auditLock serializes appends of an agent's chain within this instance.
+func auditLock(companyId, agent string) *sync.Mutex {
+	lock, _ := auditLocks.LoadOrStore(companyId+"/"+agent, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// AppendAuditEntry appends entry to the chain of its agent. Chains are keyed by company the agent is registered by, so
+// commands of objects carrying no or another company label land in the chain of their agent.
+func AppendAuditEntry(entry AuditEntry) {
+	if company := AgentCompany(entry.AgentName); company != "" {
+		entry.CompanyId = company
+	}
+	lock := auditLock(entry.CompanyId, entry.AgentName)
+	lock.Lock()
+	defer lock.Unlock()
+	// mongo keeps milliseconds, hash must be computed over the stored value
+	entry.ReceivedAt = entry.ReceivedAt.UTC().Truncate(time.Millisecond)
+	coll := db.GetDmManager().Db.Collection(AuditLogCollection)
+	for attempt := 0; attempt < auditAppendRetries; attempt++ {
+		var last AuditEntry
+		err := coll.FindOne(db.GetDmManager().Ctx, auditChainFilter(entry.CompanyId, entry.AgentName), options.FindOne().SetSort(bson.M{"sequence": -1})).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Println("[ERROR]", err)
+			return
+		}
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+		entry.Id = fmt.Sprintf("%s/%s/%d", entry.CompanyId, entry.AgentName, entry.Sequence)
+		entry.Hash = entry.computeHash()
+		_, err = coll.InsertOne(db.GetDmManager().Ctx, entry)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			log.Println("[ERROR] Insert document:", err.Error())
+			return
+		}
+		// head is only moved forward, a failing upsert means a later entry is already the head
+		upsert := true
+		_, err = db.GetDmManager().Db.Collection(AuditHeadCollection).UpdateOne(db.GetDmManager().Ctx,
+			bson.M{"_id": entry.CompanyId + "/" + entry.AgentName, "sequence": bson.M{"$lt": entry.Sequence}},
+			bson.M{"$set": auditHead{CompanyId: entry.CompanyId, AgentName: entry.AgentName, Sequence: entry.Sequence, Hash: entry.Hash}},
+			&options.UpdateOptions{Upsert: &upsert})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			log.Println("[ERROR]", err)
+		}
+		return
+	}
+	log.Println("[ERROR] Failed to append audit entry of agent", entry.AgentName+": chain is being appended concurrently")
+}
+
+// auditChainVerifier checks entries of an agent's chain in sequence order.
+type auditChainVerifier struct {
+	verification AuditVerification
+	last         AuditEntry
+}
+
+func newAuditChainVerifier(companyId, agent string) *auditChainVerifier {
+	return &auditChainVerifier{verification: AuditVerification{CompanyId: companyId, AgentName: agent, Valid: true}}
+}
+
+func (v *auditChainVerifier) invalid(sequence int64, reason string) bool {
+	v.verification.Valid = false
+	v.verification.InvalidSequence = sequence
+	v.verification.Reason = reason
+	return false
+}
+
+// next checks the entry following the last checked one, returns false if the chain is broken.
+func (v *auditChainVerifier) next(entry AuditEntry) bool {
+	v.verification.EntriesChecked++
+	if entry.Sequence != v.last.Sequence+1 {
+		return v.invalid(v.last.Sequence+1, fmt.Sprintf("entry %d is missing", v.last.Sequence+1))
+	}
+	if entry.PrevHash != v.last.Hash {
+		return v.invalid(entry.Sequence, "previous hash does not match")
+	}
+	if entry.Hash != entry.computeHash() {
+		return v.invalid(entry.Sequence, "entry has been modified")
+	}
+	v.last = entry
+	return true
+}
+
+// end checks the last entry against the chain head, detecting entries removed from the end of the chain.
+func (v *auditChainVerifier) end(head auditHead) {
+	if head.Sequence > v.last.Sequence {
+		v.invalid(v.last.Sequence+1, fmt.Sprintf("entries %d to %d are missing", v.last.Sequence+1, head.Sequence))
+	} else if head.Sequence == v.last.Sequence && head.Hash != v.last.Hash {
+		v.invalid(v.last.Sequence, "last entry does not match chain head")
+	}
+}
+
+// VerifyAuditChain checks every entry of an agent's chain, detecting modified, removed or reordered entries.
+func VerifyAuditChain(companyId, agent string) (AuditVerification, error) {
+	verifier := newAuditChainVerifier(companyId, agent)
+	coll := db.GetDmManager().Db.Collection(AuditLogCollection)
+	curser, err := coll.Find(db.GetDmManager().Ctx, auditChainFilter(companyId, agent), options.Find().SetSort(bson.M{"sequence": 1}))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return AuditVerification{}, err
+	}
+	defer curser.Close(context.TODO())
+	for curser.Next(context.TODO()) {
+		var entry AuditEntry
+		if err := curser.Decode(&entry); err != nil {
+			log.Println("[ERROR]", err)
+			return AuditVerification{}, err
+		}
+		if !verifier.next(entry) {
+			return verifier.verification, nil
+		}
+	}
+	var head auditHead
+	err = db.GetDmManager().Db.Collection(AuditHeadCollection).FindOne(db.GetDmManager().Ctx, bson.M{"_id": companyId + "/" + agent}).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Println("[ERROR]", err)
+		return AuditVerification{}, err
+	}
+	verifier.end(head)
+	return verifier.verification, nil
+}
+
+// ExportAuditLog writes entries of a company, optionally of an agent and a time range, to fn in chain order.
+func ExportAuditLog(companyId, agent string, from, to *time.Time, fn func(entry AuditEntry) error) error {
+	conditions := []bson.M{{"company": companyId}}
+	if agent != "" {
+		conditions = append(conditions, bson.M{"agent_name": agent})
+	}
+	if from != nil {
+		conditions = append(conditions, bson.M{"received_at": bson.M{"$gte": *from}})
+	}
+	if to != nil {
+		conditions = append(conditions, bson.M{"received_at": bson.M{"$lte": *to}})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "agent_name", Value: 1}, {Key: "sequence", Value: 1}})
+	curser, err := db.GetDmManager().Db.Collection(AuditLogCollection).Find(db.GetDmManager().Ctx, andFilter(conditions), opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return err
+	}
+	defer curser.Close(context.TODO())
+	for curser.Next(context.TODO()) {
+		var entry AuditEntry
+		if err := curser.Decode(&entry); err != nil {
+			log.Println("[ERROR]", err)
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return curser.Err()
+}
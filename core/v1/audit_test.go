@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"testing"
+	"time"
+)
+
+// auditChain returns a valid chain of n entries of an agent.
+func auditChain(n int) []AuditEntry {
+	entries := []AuditEntry{}
+	var last AuditEntry
+	for i := 1; i <= n; i++ {
+		entry := AuditEntry{
+			CompanyId:  "company",
+			AgentName:  "agent",
+			Sequence:   int64(i),
+			ReceivedAt: time.Date(2022, 1, 1, 0, 0, i, 0, time.UTC),
+			Command:    enums.ADD,
+			Kind:       enums.POD,
+			Namespace:  "default",
+			Name:       "web",
+			Result:     AuditSuccess,
+			StatusCode: 200,
+			PrevHash:   last.Hash,
+		}
+		entry.Hash = entry.computeHash()
+		entries = append(entries, entry)
+		last = entry
+	}
+	return entries
+}
+
+func TestAuditChainVerifier(t *testing.T) {
+	testCases := []struct {
+		name            string
+		tamper          func(entries []AuditEntry) ([]AuditEntry, auditHead)
+		valid           bool
+		invalidSequence int64
+		reason          string
+	}{
+		{
+			name: "intact chain",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				return entries, auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			valid: true,
+		},
+		{
+			name: "modified entry",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				entries[1].Name = "db"
+				return entries, auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			invalidSequence: 2,
+			reason:          "entry has been modified",
+		},
+		{
+			name: "modified entry with recomputed hash",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				entries[1].Result = AuditFailed
+				entries[1].Hash = entries[1].computeHash()
+				return entries, auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			invalidSequence: 3,
+			reason:          "previous hash does not match",
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				return []AuditEntry{entries[0], entries[2]}, auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			invalidSequence: 2,
+			reason:          "entry 2 is missing",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				entries[1].Sequence, entries[2].Sequence = entries[2].Sequence, entries[1].Sequence
+				return []AuditEntry{entries[0], entries[2], entries[1]}, auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			invalidSequence: 2,
+			reason:          "previous hash does not match",
+		},
+		{
+			name: "entries removed from the end",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				return entries[:1], auditHead{Sequence: 3, Hash: entries[2].Hash}
+			},
+			invalidSequence: 2,
+			reason:          "entries 2 to 3 are missing",
+		},
+		{
+			name: "last entry replaced",
+			tamper: func(entries []AuditEntry) ([]AuditEntry, auditHead) {
+				head := auditHead{Sequence: 3, Hash: entries[2].Hash}
+				entries[2].Name = "db"
+				entries[2].Hash = entries[2].computeHash()
+				return entries, head
+			},
+			invalidSequence: 3,
+			reason:          "last entry does not match chain head",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			entries, head := testCase.tamper(auditChain(3))
+			verifier := newAuditChainVerifier("company", "agent")
+			for _, each := range entries {
+				if !verifier.next(each) {
+					break
+				}
+			}
+			if verifier.verification.Valid {
+				verifier.end(head)
+			}
+			verification := verifier.verification
+			if verification.Valid != testCase.valid {
+				t.Fatalf("valid = %v, want %v (%s)", verification.Valid, testCase.valid, verification.Reason)
+			}
+			if verification.InvalidSequence != testCase.invalidSequence || verification.Reason != testCase.reason {
+				t.Errorf("invalid at %d: %q, want %d: %q", verification.InvalidSequence, verification.Reason, testCase.invalidSequence, testCase.reason)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type Certificate struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sCertificate `bson:"obj" json:"obj"`
 	AgentName          string         `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Certificate) findByNameAndNamespaceAndCompanyId() K8sCertificate {
@@ -52,6 +54,7 @@ func NewCertificate() KubeObject {
 
 func (obj Certificate) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.CERTIFICATE, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(CertificateCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -88,7 +91,9 @@ func (obj Certificate) findById() K8sCertificate {
 	return temp.Obj
 }
 
-func (obj Certificate) Delete(agent string) error {
+func (obj Certificate) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.CERTIFICATE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -97,7 +102,7 @@ func (obj Certificate) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(CertificateCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -114,6 +119,7 @@ func (obj Certificate) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.CERTIFICATE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"regexp"
+	"time"
+)
+
+// ObjectChange field changes of an applied UPDATE.
+type ObjectChange struct {
+	Kind       enums.RESOURCE_TYPE `json:"kind" bson:"kind"`
+	CompanyId  string              `json:"company" bson:"company"`
+	AgentName  string              `json:"agent_name" bson:"agent_name"`
+	Namespace  string              `json:"namespace" bson:"namespace"`
+	Name       string              `json:"name" bson:"name"`
+	Offset     int                 `json:"offset" bson:"offset"`
+	RecordedAt time.Time           `json:"recorded_at" bson:"recorded_at"`
+	Changes    []FieldChange       `json:"changes" bson:"changes"`
+}
+
+// ChangeQuery scope and page of a field changes query. Field matches the last segments of a change path,
+// e.g. image matches spec.template.spec.containers[web].image.
+type ChangeQuery struct {
+	Type      enums.RESOURCE_TYPE
+	CompanyId string
+	AgentName string
+	Namespace string
+	Name      string
+	Field     string
+	Op        string
+	From      *time.Time
+	To        *time.Time
+	Page      int64
+	Limit     int64
+}
+
+func (q ChangeQuery) fieldPattern() *regexp.Regexp {
+	if q.Field == "" {
+		return nil
+	}
+	return regexp.MustCompile(`(^|[.\]])` + regexp.QuoteMeta(q.Field) + `$`)
+}
+
+func (q ChangeQuery) filter() bson.M {
+	change := bson.M{}
+	if pattern := q.fieldPattern(); pattern != nil {
+		change["path"] = bson.M{"$regex": pattern.String()}
+	}
+	if q.Op != "" {
+		change["op"] = q.Op
+	}
+	conditions := []bson.M{
+		{"command": enums.UPDATE},
+		{"changes": bson.M{"$elemMatch": change}},
+	}
+	if len(change) == 0 {
+		conditions[1] = bson.M{"changes.0": bson.M{"$exists": true}}
+	}
+	for key, value := range map[string]string{
+		"kind":       string(q.Type),
+		"company":    q.CompanyId,
+		"agent_name": q.AgentName,
+		"namespace":  q.Namespace,
+		"name":       q.Name,
+	} {
+		if value != "" {
+			conditions = append(conditions, bson.M{key: value})
+		}
+	}
+	if q.From != nil {
+		conditions = append(conditions, bson.M{"recorded_at": bson.M{"$gte": *q.From}})
+	}
+	if q.To != nil {
+		conditions = append(conditions, bson.M{"recorded_at": bson.M{"$lte": *q.To}})
+	}
+	return andFilter(conditions)
+}
+
+// matches reports whether a field change satisfies field and op of the query.
+func (q ChangeQuery) matches(pattern *regexp.Regexp, change FieldChange) bool {
+	if q.Op != "" && change.Op != q.Op {
+		return false
+	}
+	return pattern == nil || pattern.MatchString(change.Path)
+}
+
+// FindChanges returns a page of field changes of updates, latest first, and total count of matching updates.
+// Only changes matching field and op of the query are returned.
+func FindChanges(q ChangeQuery) ([]ObjectChange, int64, error) {
+	if q.Type != "" {
+		if _, ok := GetResourceDescriptor(q.Type); !ok {
+			return nil, 0, ErrUnknownResource
+		}
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	} else if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	filter := q.filter()
+	coll := db.GetDmManager().Db.Collection(RevisionCollection)
+	total, err := coll.CountDocuments(db.GetDmManager().Ctx, filter)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"obj": 0}).
+		SetSort(bson.D{{Key: "recorded_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(q.Page * q.Limit).
+		SetLimit(q.Limit)
+	curser, err := coll.Find(db.GetDmManager().Ctx, filter, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	defer curser.Close(context.TODO())
+	pattern := q.fieldPattern()
+	objectChanges := []ObjectChange{}
+	for curser.Next(context.TODO()) {
+		var objectChange ObjectChange
+		if err := curser.Decode(&objectChange); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		changes := []FieldChange{}
+		for _, each := range objectChange.Changes {
+			if q.matches(pattern, each) {
+				changes = append(changes, each)
+			}
+		}
+		objectChange.Changes = changes
+		objectChanges = append(objectChanges, objectChange)
+	}
+	return objectChanges, total, nil
+}
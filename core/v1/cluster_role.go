@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type ClusterRole struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sClusterRole `bson:"obj" json:"obj"`
 	AgentName          string         `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj ClusterRole) deleteAll() error {
@@ -43,6 +45,7 @@ func NewClusterRole() KubeObject {
 
 func (obj ClusterRole) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.CLUSTER_ROLE, &obj.Obj)
 	if obj.findByNameAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(ClusterRoleCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -96,7 +99,9 @@ func (obj ClusterRole) findByNameAndCompanyId() K8sClusterRole {
 	return temp.Obj
 }
 
-func (obj ClusterRole) Delete(agent string) error {
+func (obj ClusterRole) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.CLUSTER_ROLE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -104,7 +109,7 @@ func (obj ClusterRole) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ClusterRoleCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -135,6 +140,7 @@ func (obj ClusterRole) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.CLUSTER_ROLE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type ClusterRoleBinding struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                k8sClusterRoleBinding `bson:"obj" json:"obj"`
 	AgentName          string                `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj ClusterRoleBinding) deleteAll() error {
@@ -44,6 +46,7 @@ func NewClusterRoleBinding() KubeObject {
 
 func (obj ClusterRoleBinding) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.CLUSTER_ROLE_BINDGING, &obj.Obj)
 	if obj.findByNameAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(ClusterRoleBindingCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -123,7 +126,9 @@ func (object ClusterRoleBinding) findBykubeAgentName() []k8sClusterRoleBinding {
 	return k8sObjects
 }
 
-func (obj ClusterRoleBinding) Delete(agent string) error {
+func (obj ClusterRoleBinding) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.CLUSTER_ROLE_BINDGING, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -131,7 +136,7 @@ func (obj ClusterRoleBinding) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ClusterRoleBindingCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -148,6 +153,7 @@ func (obj ClusterRoleBinding) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.CLUSTER_ROLE_BINDGING, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -0,0 +1,216 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// DefaultConfigImpactWindow refers to how long after a config change pod restarts are attributed to it.
+const DefaultConfigImpactWindow = 10 * time.Minute
+
+// DefaultConfigImpactRange refers to how far back config changes are reported when no range is requested.
+const DefaultConfigImpactRange = 24 * time.Hour
+
+// crashLoopReason waiting reason of a container restarted repeatedly.
+const crashLoopReason = "CrashLoopBackOff"
+
+// ContainerRestart restarts of a container observed after a config change.
+type ContainerRestart struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// Restarts increase of restart count since the config change.
+	Restarts     int32     `json:"restarts"`
+	RestartCount int32     `json:"restart_count"`
+	ObservedAt   time.Time `json:"observed_at"`
+	Reason       string    `json:"reason,omitempty"`
+	ExitCode     int32     `json:"exit_code,omitempty"`
+	CrashLoop    bool      `json:"crash_loop"`
+}
+
+// ConfigChangeImpact config change along with restarts of pods using the config within the window following it.
+type ConfigChangeImpact struct {
+	Kind         enums.RESOURCE_TYPE `json:"kind"`
+	Namespace    string              `json:"namespace"`
+	Name         string              `json:"name"`
+	Command      enums.Command       `json:"command"`
+	ChangedAt    time.Time           `json:"changed_at"`
+	ChangedPaths []string            `json:"changed_paths,omitempty"`
+	// Pods pods using the config within the window.
+	Pods     []string           `json:"pods"`
+	Restarts []ContainerRestart `json:"restarts"`
+}
+
+// ConfigImpactQuery scope of a config change impact report.
+type ConfigImpactQuery struct {
+	CompanyId string
+	AgentName string
+	Namespace string
+	From      *time.Time
+	To        *time.Time
+	Window    time.Duration
+	// OnlyImpacting limits to changes followed by restarts or crash loops.
+	OnlyImpacting bool
+}
+
+// revisionPod returns pod of a pod revision.
+func revisionPod(revision Revision) (K8sPod, error) {
+	var pod K8sPod
+	err := revision.decode(&pod)
+	return pod, err
+}
+
+// references returns true if the pod references the config.
+func (p K8sPod) references(object enums.RESOURCE_TYPE, name string) bool {
+	kind := "ConfigMap"
+	if object == enums.SECRET {
+		kind = "Secret"
+	}
+	for _, each := range p.configReferences() {
+		if each.Kind == kind && each.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// podRestarts returns restarts of containers of a pod observed in its revisions, relative to restart counts of baseline.
+func podRestarts(name string, baseline map[string]int32, revisions []Revision) []ContainerRestart {
+	restarts := []ContainerRestart{}
+	counts := make(map[string]int32, len(baseline))
+	for container, count := range baseline {
+		counts[container] = count
+	}
+	crashLooping := make(map[string]bool)
+	for _, revision := range revisions {
+		pod, err := revisionPod(revision)
+		if err != nil {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			crashLoop := status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopReason
+			if status.RestartCount <= counts[status.Name] && (!crashLoop || crashLooping[status.Name]) {
+				continue
+			}
+			restart := ContainerRestart{
+				Pod:          name,
+				Container:    status.Name,
+				RestartCount: status.RestartCount,
+				ObservedAt:   revision.RecordedAt,
+				CrashLoop:    crashLoop,
+			}
+			if status.RestartCount > counts[status.Name] {
+				restart.Restarts = status.RestartCount - counts[status.Name]
+			}
+			if status.LastTerminationState.Terminated != nil {
+				restart.Reason = status.LastTerminationState.Terminated.Reason
+				restart.ExitCode = status.LastTerminationState.Terminated.ExitCode
+			}
+			counts[status.Name] = status.RestartCount
+			crashLooping[status.Name] = crashLoop
+			restarts = append(restarts, restart)
+		}
+	}
+	return restarts
+}
+
+// impactOf returns pods using the changed config and their restarts within the window following the change.
+func (q ConfigImpactQuery) impactOf(change Revision) (ConfigChangeImpact, error) {
+	impact := ConfigChangeImpact{
+		Kind:      change.Kind,
+		Namespace: change.Namespace,
+		Name:      change.Name,
+		Command:   change.Command,
+		ChangedAt: change.RecordedAt,
+		Pods:      []string{},
+		Restarts:  []ContainerRestart{},
+	}
+	for _, each := range change.Changes {
+		impact.ChangedPaths = append(impact.ChangedPaths, each.Path)
+	}
+	until := change.RecordedAt.Add(q.Window)
+	podRevisions, err := findRevisionsInRange(RevisionQuery{
+		Type:      enums.POD,
+		CompanyId: q.CompanyId,
+		AgentName: q.AgentName,
+		Namespace: change.Namespace,
+		From:      &change.RecordedAt,
+		To:        &until,
+	}.filter())
+	if err != nil {
+		return ConfigChangeImpact{}, err
+	}
+	byPod := make(map[string][]Revision)
+	names := []string{}
+	for _, revision := range podRevisions {
+		if _, ok := byPod[revision.Name]; !ok {
+			names = append(names, revision.Name)
+		}
+		byPod[revision.Name] = append(byPod[revision.Name], revision)
+	}
+	for _, name := range names {
+		revisions := byPod[name]
+		pod, err := revisionPod(revisions[len(revisions)-1])
+		if err != nil || !pod.references(change.Kind, change.Name) {
+			continue
+		}
+		impact.Pods = append(impact.Pods, name)
+		baseline := make(map[string]int32)
+		previous, err := FindRevisionAsOf(RevisionQuery{Type: enums.POD, CompanyId: q.CompanyId, AgentName: q.AgentName, Namespace: change.Namespace, Name: name}, change.RecordedAt)
+		if err == nil {
+			if previousPod, err := revisionPod(previous); err == nil {
+				for _, status := range previousPod.Status.ContainerStatuses {
+					baseline[status.Name] = status.RestartCount
+				}
+			}
+		} else if err != ErrRevisionNotFound {
+			return ConfigChangeImpact{}, err
+		}
+		impact.Restarts = append(impact.Restarts, podRestarts(name, baseline, revisions)...)
+	}
+	return impact, nil
+}
+
+// FindConfigChangeImpacts returns configmap and secret updates and deletions of an agent, each with restarts and crash
+// loops of pods using the config within the window following the change.
+func FindConfigChangeImpacts(q ConfigImpactQuery) ([]ConfigChangeImpact, error) {
+	if q.Window <= 0 {
+		q.Window = DefaultConfigImpactWindow
+	}
+	if q.To == nil {
+		now := time.Now().UTC()
+		q.To = &now
+	}
+	if q.From == nil {
+		from := q.To.Add(-DefaultConfigImpactRange)
+		q.From = &from
+	}
+	conditions := []bson.M{
+		{"kind": bson.M{"$in": []enums.RESOURCE_TYPE{enums.CONFIG_MAP, enums.SECRET}}},
+		{"command": bson.M{"$in": []enums.Command{enums.UPDATE, enums.DELETE}}},
+		{"agent_name": q.AgentName},
+		{"recorded_at": bson.M{"$gte": *q.From, "$lte": *q.To}},
+	}
+	if q.CompanyId != "" {
+		conditions = append(conditions, bson.M{"company": q.CompanyId})
+	}
+	if q.Namespace != "" {
+		conditions = append(conditions, bson.M{"namespace": q.Namespace})
+	}
+	changes, err := findRevisionsInRange(andFilter(conditions))
+	if err != nil {
+		return nil, err
+	}
+	impacts := []ConfigChangeImpact{}
+	for _, change := range changes {
+		impact, err := q.impactOf(change)
+		if err != nil {
+			return nil, err
+		}
+		if q.OnlyImpacting && len(impact.Restarts) == 0 {
+			continue
+		}
+		impacts = append(impacts, impact)
+	}
+	return impacts, nil
+}
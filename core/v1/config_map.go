@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type ConfigMap struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sConfigMap `bson:"obj" json:"obj"`
 	AgentName          string       `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj ConfigMap) deleteAll() error {
@@ -44,6 +46,7 @@ func NewConfigMap() KubeObject {
 
 func (obj ConfigMap) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.CONFIG_MAP, &obj.Obj)
 	existing := obj.findByNameAndNamespaceAndCompanyId()
 	if existing.ObjectMeta.Name == "" {
 		coll := db.GetDmManager().Db.Collection(ConfigmapCollection)
@@ -100,7 +103,9 @@ func (object ConfigMap) findByNameAndNamespaceAndCompanyId() K8sConfigMap {
 	return temp.Obj
 }
 
-func (obj ConfigMap) Delete(agent string) error {
+func (obj ConfigMap) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.CONFIG_MAP, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -109,7 +114,7 @@ func (obj ConfigMap) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ConfigmapCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -126,6 +131,7 @@ func (obj ConfigMap) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.CONFIG_MAP, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
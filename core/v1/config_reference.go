@@ -0,0 +1,91 @@
+package v1
+
+const (
+	// ConfigSourceVolume configmap or secret volume.
+	ConfigSourceVolume = "volume"
+	// ConfigSourceProjected source of a projected volume.
+	ConfigSourceProjected = "projected"
+	// ConfigSourceEnvFrom every key exposed as environment variables.
+	ConfigSourceEnvFrom = "envFrom"
+	// ConfigSourceEnv single key exposed as an environment variable.
+	ConfigSourceEnv = "env"
+	// ConfigSourceImagePullSecret secret used to pull images.
+	ConfigSourceImagePullSecret = "imagePullSecret"
+)
+
+// ConfigReference reference of a pod to a configmap or secret.
+type ConfigReference struct {
+	// Kind ConfigMap or Secret.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Keys referenced keys, empty if every key is used.
+	Keys []string `json:"keys,omitempty"`
+	// Source how the pod uses the object, one of volume, projected, envFrom, env and imagePullSecret.
+	Source string `json:"source"`
+	// Container set for references of env and envFrom.
+	Container string `json:"container,omitempty"`
+	// Volume set for references of volume and projected.
+	Volume   string `json:"volume,omitempty"`
+	Optional bool   `json:"optional"`
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+func keysOf(items []KeyToPath) []string {
+	keys := []string{}
+	for _, each := range items {
+		keys = append(keys, each.Key)
+	}
+	return keys
+}
+
+// configReferences returns every reference of the pod to configmaps and secrets, through volumes, projected volumes,
+// envFrom and env of init and app containers and image pull secrets.
+func (p K8sPod) configReferences() []ConfigReference {
+	references := []ConfigReference{}
+	for _, each := range p.Spec.ImagePullSecrets {
+		references = append(references, ConfigReference{Kind: "Secret", Name: each.Name, Source: ConfigSourceImagePullSecret})
+	}
+	for _, volume := range p.Spec.Volumes {
+		if volume.ConfigMap != nil {
+			references = append(references, ConfigReference{Kind: "ConfigMap", Name: volume.ConfigMap.Name, Keys: keysOf(volume.ConfigMap.Items), Source: ConfigSourceVolume, Volume: volume.Name, Optional: isOptional(volume.ConfigMap.Optional)})
+		}
+		if volume.Secret != nil {
+			references = append(references, ConfigReference{Kind: "Secret", Name: volume.Secret.SecretName, Keys: keysOf(volume.Secret.Items), Source: ConfigSourceVolume, Volume: volume.Name, Optional: isOptional(volume.Secret.Optional)})
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					references = append(references, ConfigReference{Kind: "ConfigMap", Name: source.ConfigMap.Name, Keys: keysOf(source.ConfigMap.Items), Source: ConfigSourceProjected, Volume: volume.Name, Optional: isOptional(source.ConfigMap.Optional)})
+				}
+				if source.Secret != nil {
+					references = append(references, ConfigReference{Kind: "Secret", Name: source.Secret.Name, Keys: keysOf(source.Secret.Items), Source: ConfigSourceProjected, Volume: volume.Name, Optional: isOptional(source.Secret.Optional)})
+				}
+			}
+		}
+	}
+	for _, container := range append(append([]Container{}, p.Spec.InitContainers...), p.Spec.Containers...) {
+		for _, each := range container.EnvFrom {
+			if each.ConfigMapRef != nil {
+				references = append(references, ConfigReference{Kind: "ConfigMap", Name: each.ConfigMapRef.Name, Source: ConfigSourceEnvFrom, Container: container.Name, Optional: isOptional(each.ConfigMapRef.Optional)})
+			}
+			if each.SecretRef != nil {
+				references = append(references, ConfigReference{Kind: "Secret", Name: each.SecretRef.Name, Source: ConfigSourceEnvFrom, Container: container.Name, Optional: isOptional(each.SecretRef.Optional)})
+			}
+		}
+		for _, each := range container.Env {
+			if each.ValueFrom == nil {
+				continue
+			}
+			if each.ValueFrom.ConfigMapKeyRef != nil {
+				references = append(references, ConfigReference{Kind: "ConfigMap", Name: each.ValueFrom.ConfigMapKeyRef.Name, Keys: []string{each.ValueFrom.ConfigMapKeyRef.Key}, Source: ConfigSourceEnv, Container: container.Name, Optional: isOptional(each.ValueFrom.ConfigMapKeyRef.Optional)})
+			}
+			if each.ValueFrom.SecretKeyRef != nil {
+				references = append(references, ConfigReference{Kind: "Secret", Name: each.ValueFrom.SecretKeyRef.Name, Keys: []string{each.ValueFrom.SecretKeyRef.Key}, Source: ConfigSourceEnv, Container: container.Name, Optional: isOptional(each.ValueFrom.SecretKeyRef.Optional)})
+			}
+		}
+	}
+	return references
+}
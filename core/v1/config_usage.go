@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"sort"
+)
+
+// ConfigUser pod using a configmap or secret, along with its references to it.
+type ConfigUser struct {
+	Pod        string            `json:"pod"`
+	References []ConfigReference `json:"references"`
+}
+
+// ConfigUsage configmap or secret along with pods using it.
+type ConfigUsage struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Keys stored keys of the object.
+	Keys []string `json:"keys"`
+	// UnusedKeys stored keys no pod references, empty if a pod uses every key.
+	UnusedKeys []string     `json:"unused_keys"`
+	Pods       []ConfigUser `json:"pods"`
+	// Ingresses ingresses terminating tls with the secret.
+	Ingresses []string `json:"ingresses,omitempty"`
+}
+
+// MissingConfigReference reference of a pod to a configmap or secret, or to keys of it, that are not stored.
+type MissingConfigReference struct {
+	Pod       string          `json:"pod"`
+	Namespace string          `json:"namespace"`
+	Reference ConfigReference `json:"reference"`
+	// MissingKeys referenced keys that are not stored, empty if the whole object is missing.
+	MissingKeys []string `json:"missing_keys,omitempty"`
+}
+
+// ConfigUsageReport usage of configmaps and secrets by pods.
+type ConfigUsageReport struct {
+	ConfigMaps []ConfigUsage `json:"config_maps"`
+	Secrets    []ConfigUsage `json:"secrets"`
+	// Unused configmaps and secrets no pod or ingress uses. Service account tokens are not reported.
+	Unused []ConfigUsage `json:"unused"`
+	// MissingReferences non optional references to objects or keys that are not stored.
+	MissingReferences []MissingConfigReference `json:"missing_references"`
+}
+
+type configUsageEntry struct {
+	usage        ConfigUsage
+	keys         map[string]bool
+	usedKeys     map[string]bool
+	allKeys      bool
+	pods         map[string]int
+	ignoreUnused bool
+}
+
+func (e *configUsageEntry) addReference(pod string, reference ConfigReference) {
+	index, ok := e.pods[pod]
+	if !ok {
+		index = len(e.usage.Pods)
+		e.pods[pod] = index
+		e.usage.Pods = append(e.usage.Pods, ConfigUser{Pod: pod, References: []ConfigReference{}})
+	}
+	e.usage.Pods[index].References = append(e.usage.Pods[index].References, reference)
+	if reference.Source == ConfigSourceImagePullSecret {
+		return
+	}
+	if len(reference.Keys) == 0 {
+		e.allKeys = true
+	}
+	for _, key := range reference.Keys {
+		e.usedKeys[key] = true
+	}
+}
+
+func newConfigUsageEntry(kind string, meta ObjectMeta, keys map[string]bool) *configUsageEntry {
+	entry := &configUsageEntry{
+		usage:    ConfigUsage{Kind: kind, Namespace: meta.Namespace, Name: meta.Name, Keys: []string{}, UnusedKeys: []string{}, Pods: []ConfigUser{}},
+		keys:     keys,
+		usedKeys: make(map[string]bool),
+		pods:     make(map[string]int),
+	}
+	for key := range keys {
+		entry.usage.Keys = append(entry.usage.Keys, key)
+	}
+	sort.Strings(entry.usage.Keys)
+	return entry
+}
+
+func configUsageKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// AnalyzeConfigUsage maps every stored configmap and secret of an agent, optionally in a namespace, to the pods using it,
+// reporting unused ones and references of pods to objects or keys that are not stored.
+func AnalyzeConfigUsage(companyId, agent, namespace string) (ConfigUsageReport, error) {
+	entries := make(map[string]*configUsageEntry)
+	configMaps, err := findLiveObjects(enums.CONFIG_MAP, companyId, agent, namespace)
+	if err != nil {
+		return ConfigUsageReport{}, err
+	}
+	for _, each := range configMaps {
+		configMap := each.(*ConfigMap).Obj
+		keys := make(map[string]bool)
+		for key := range configMap.Data {
+			keys[key] = true
+		}
+		for key := range configMap.BinaryData {
+			keys[key] = true
+		}
+		entries[configUsageKey("ConfigMap", configMap.Namespace, configMap.Name)] = newConfigUsageEntry("ConfigMap", configMap.ObjectMeta, keys)
+	}
+	secrets, err := findLiveObjects(enums.SECRET, companyId, agent, namespace)
+	if err != nil {
+		return ConfigUsageReport{}, err
+	}
+	for _, each := range secrets {
+		secret := each.(*Secret).Obj
+		keys := make(map[string]bool)
+		for key := range secret.Data {
+			keys[key] = true
+		}
+		for key := range secret.StringData {
+			keys[key] = true
+		}
+		entry := newConfigUsageEntry("Secret", secret.ObjectMeta, keys)
+		entry.ignoreUnused = secret.Type == SecretTypeServiceAccountToken
+		entries[configUsageKey("Secret", secret.Namespace, secret.Name)] = entry
+	}
+	pods, err := findPods(companyId, agent, namespace)
+	if err != nil {
+		return ConfigUsageReport{}, err
+	}
+	report := ConfigUsageReport{
+		ConfigMaps:        []ConfigUsage{},
+		Secrets:           []ConfigUsage{},
+		Unused:            []ConfigUsage{},
+		MissingReferences: []MissingConfigReference{},
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	for _, pod := range pods {
+		for _, reference := range pod.configReferences() {
+			entry, ok := entries[configUsageKey(reference.Kind, pod.Namespace, reference.Name)]
+			if !ok {
+				if !reference.Optional {
+					report.MissingReferences = append(report.MissingReferences, MissingConfigReference{Pod: pod.Name, Namespace: pod.Namespace, Reference: reference})
+				}
+				continue
+			}
+			entry.addReference(pod.Name, reference)
+			missingKeys := []string{}
+			for _, key := range reference.Keys {
+				if !entry.keys[key] {
+					missingKeys = append(missingKeys, key)
+				}
+			}
+			if len(missingKeys) > 0 && !reference.Optional {
+				report.MissingReferences = append(report.MissingReferences, MissingConfigReference{Pod: pod.Name, Namespace: pod.Namespace, Reference: reference, MissingKeys: missingKeys})
+			}
+		}
+	}
+	ingresses, err := findLiveObjects(enums.INGRESS, companyId, agent, namespace)
+	if err != nil {
+		return ConfigUsageReport{}, err
+	}
+	for _, each := range ingresses {
+		ingress := each.(*Ingress).Obj
+		for _, tls := range ingress.Spec.TLS {
+			if entry, ok := entries[configUsageKey("Secret", ingress.Namespace, tls.SecretName)]; ok {
+				entry.usage.Ingresses = append(entry.usage.Ingresses, ingress.Name)
+			}
+		}
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry := entries[key]
+		if !entry.allKeys && (len(entry.usedKeys) > 0 || len(entry.usage.Pods) == 0) {
+			for _, each := range entry.usage.Keys {
+				if !entry.usedKeys[each] {
+					entry.usage.UnusedKeys = append(entry.usage.UnusedKeys, each)
+				}
+			}
+		}
+		if len(entry.usage.Pods) == 0 && len(entry.usage.Ingresses) == 0 && !entry.ignoreUnused {
+			report.Unused = append(report.Unused, entry.usage)
+		}
+		if entry.usage.Kind == "ConfigMap" {
+			report.ConfigMaps = append(report.ConfigMaps, entry.usage)
+		} else {
+			report.Secrets = append(report.Secrets, entry.usage)
+		}
+	}
+	return report, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type DaemonSet struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sDaemonSet `bson:"obj" json:"obj"`
 	AgentName          string       `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj DaemonSet) deleteAll() error {
@@ -42,6 +44,7 @@ func NewDaemonSet() KubeObject {
 }
 func (obj DaemonSet) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.DAEMONSET, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(DaemonSetCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -97,7 +100,9 @@ func (obj DaemonSet) findByNameAndNamespaceAndCompanyId() K8sDaemonSet {
 	return temp.Obj
 }
 
-func (obj DaemonSet) Delete(agent string) error {
+func (obj DaemonSet) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.DAEMONSET, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -106,7 +111,7 @@ func (obj DaemonSet) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(DaemonSetCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -123,6 +128,7 @@ func (obj DaemonSet) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.DAEMONSET, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
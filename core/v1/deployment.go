@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type Deployment struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sDeployment `bson:"obj" json:"obj"`
 	AgentName          string        `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Deployment) deleteAll() error {
@@ -43,6 +45,7 @@ func NewDeployment() KubeObject {
 }
 func (obj Deployment) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.DEPLOYMENT, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(DeploymentCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj Deployment) findByNameAndNamespaceAndCompanyId() K8sDeployment {
 	return temp.Obj
 }
 
-func (obj Deployment) Delete(agent string) error {
+func (obj Deployment) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.DEPLOYMENT, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -107,7 +112,7 @@ func (obj Deployment) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(DeploymentCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 
 	if err != nil {
 		log.Println("[DELETING ERROR]", err)
@@ -125,6 +130,7 @@ func (obj Deployment) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.DEPLOYMENT, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": oldObject.Obj.Name},
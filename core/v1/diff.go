@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// ChangeAdd field added.
+	ChangeAdd = "add"
+	// ChangeRemove field removed.
+	ChangeRemove = "remove"
+	// ChangeReplace field value replaced.
+	ChangeReplace = "replace"
+)
+
+// diffIgnoredPaths fields changing on every update without carrying a change of the object.
+var diffIgnoredPaths = map[string]bool{
+	"metadata.resourceVersion": true,
+	"metadata.managedFields":   true,
+}
+
+// FieldChange change of a single field between two versions of an object. Path is dot separated, list entries
+// having a name are addressed by name, e.g. spec.template.spec.containers[web].image, others by index.
+type FieldChange struct {
+	Path string      `json:"path" bson:"path"`
+	Op   string      `json:"op" bson:"op"`
+	Old  interface{} `json:"old,omitempty" bson:"old,omitempty"`
+	New  interface{} `json:"new,omitempty" bson:"new,omitempty"`
+}
+
+// Diff returns field level changes between json forms of two versions of an object.
+func Diff(old, new map[string]interface{}) []FieldChange {
+	changes := []FieldChange{}
+	diffValues("", old, new, &changes)
+	return changes
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func diffValues(path string, old, new interface{}, changes *[]FieldChange) {
+	if diffIgnoredPaths[path] {
+		return
+	}
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := joinPath(path, key)
+			oldValue, inOld := oldMap[key]
+			newValue, inNew := newMap[key]
+			if diffIgnoredPaths[child] {
+				continue
+			}
+			if !inNew {
+				*changes = append(*changes, FieldChange{Path: child, Op: ChangeRemove, Old: oldValue})
+			} else if !inOld {
+				*changes = append(*changes, FieldChange{Path: child, Op: ChangeAdd, New: newValue})
+			} else {
+				diffValues(child, oldValue, newValue, changes)
+			}
+		}
+		return
+	}
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		if oldNamed, ok := namedEntries(oldList); ok {
+			if newNamed, ok := namedEntries(newList); ok {
+				diffNamedEntries(path, oldList, newList, oldNamed, newNamed, changes)
+				return
+			}
+		}
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			if i >= len(newList) {
+				*changes = append(*changes, FieldChange{Path: child, Op: ChangeRemove, Old: oldList[i]})
+			} else if i >= len(oldList) {
+				*changes = append(*changes, FieldChange{Path: child, Op: ChangeAdd, New: newList[i]})
+			} else {
+				diffValues(child, oldList[i], newList[i], changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, FieldChange{Path: path, Op: ChangeReplace, Old: old, New: new})
+	}
+}
+
+// namedEntries returns index of list entries by name, if every entry is an object with a unique name.
+func namedEntries(list []interface{}) (map[string]int, bool) {
+	if len(list) == 0 {
+		return map[string]int{}, true
+	}
+	names := make(map[string]int, len(list))
+	for i, each := range list {
+		entry, ok := each.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, ok := entry["name"].(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+		if _, duplicate := names[name]; duplicate {
+			return nil, false
+		}
+		names[name] = i
+	}
+	return names, true
+}
+
+func diffNamedEntries(path string, oldList, newList []interface{}, oldNamed, newNamed map[string]int, changes *[]FieldChange) {
+	if len(oldList) == 0 && len(newList) == 0 {
+		return
+	}
+	names := make([]string, 0, len(oldNamed)+len(newNamed))
+	for name := range oldNamed {
+		names = append(names, name)
+	}
+	for name := range newNamed {
+		if _, ok := oldNamed[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		child := path + "[" + name + "]"
+		oldIndex, inOld := oldNamed[name]
+		newIndex, inNew := newNamed[name]
+		if !inNew {
+			*changes = append(*changes, FieldChange{Path: child, Op: ChangeRemove, Old: oldList[oldIndex]})
+		} else if !inOld {
+			*changes = append(*changes, FieldChange{Path: child, Op: ChangeAdd, New: newList[newIndex]})
+		} else {
+			diffValues(child, oldList[oldIndex], newList[newIndex], changes)
+		}
+	}
+}
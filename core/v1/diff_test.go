@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name    string
+		old     string
+		new     string
+		changes []string
+	}{
+		{
+			name:    "unchanged",
+			old:     `{"spec":{"ports":[80,443]}}`,
+			new:     `{"spec":{"ports":[80,443]}}`,
+			changes: []string{},
+		},
+		{
+			name:    "ignored fields",
+			old:     `{"metadata":{"name":"web","resourceVersion":"1","managedFields":[{"manager":"a"}]}}`,
+			new:     `{"metadata":{"name":"web","resourceVersion":"2","managedFields":[{"manager":"b"}]}}`,
+			changes: []string{},
+		},
+		{
+			name:    "field added, removed and replaced",
+			old:     `{"metadata":{"labels":{"app":"web","tier":"fe"}},"spec":{"replicas":1}}`,
+			new:     `{"metadata":{"labels":{"app":"web","team":"a"}},"spec":{"replicas":3}}`,
+			changes: []string{"add metadata.labels.team", "remove metadata.labels.tier", "replace spec.replicas"},
+		},
+		{
+			name:    "list entry added",
+			old:     `{"spec":{"ports":[80]}}`,
+			new:     `{"spec":{"ports":[80,443]}}`,
+			changes: []string{"add spec.ports[1]"},
+		},
+		{
+			name:    "list entry removed",
+			old:     `{"spec":{"ports":[80,443]}}`,
+			new:     `{"spec":{"ports":[80]}}`,
+			changes: []string{"remove spec.ports[1]"},
+		},
+		{
+			name:    "list reordered",
+			old:     `{"spec":{"ports":[80,443]}}`,
+			new:     `{"spec":{"ports":[443,80]}}`,
+			changes: []string{"replace spec.ports[0]", "replace spec.ports[1]"},
+		},
+		{
+			name:    "named entry changed",
+			old:     `{"spec":{"containers":[{"name":"web","image":"web:1"},{"name":"sidecar","image":"proxy:1"}]}}`,
+			new:     `{"spec":{"containers":[{"name":"web","image":"web:2"},{"name":"sidecar","image":"proxy:1"}]}}`,
+			changes: []string{"replace spec.containers[web].image"},
+		},
+		{
+			name:    "named entry added",
+			old:     `{"spec":{"containers":[{"name":"web","image":"web:1"}]}}`,
+			new:     `{"spec":{"containers":[{"name":"web","image":"web:1"},{"name":"sidecar","image":"proxy:1"}]}}`,
+			changes: []string{"add spec.containers[sidecar]"},
+		},
+		{
+			name:    "named entry removed",
+			old:     `{"spec":{"containers":[{"name":"web","image":"web:1"},{"name":"sidecar","image":"proxy:1"}]}}`,
+			new:     `{"spec":{"containers":[{"name":"web","image":"web:1"}]}}`,
+			changes: []string{"remove spec.containers[sidecar]"},
+		},
+		{
+			name:    "named entries reordered",
+			old:     `{"spec":{"containers":[{"name":"web","image":"web:1"},{"name":"sidecar","image":"proxy:1"}]}}`,
+			new:     `{"spec":{"containers":[{"name":"sidecar","image":"proxy:1"},{"name":"web","image":"web:1"}]}}`,
+			changes: []string{},
+		},
+		{
+			name:    "named entries reordered and changed",
+			old:     `{"spec":{"containers":[{"name":"web","env":[{"name":"A","value":"1"}]},{"name":"sidecar"}]}}`,
+			new:     `{"spec":{"containers":[{"name":"sidecar"},{"name":"web","env":[{"name":"A","value":"2"}]}]}}`,
+			changes: []string{"replace spec.containers[web].env[A].value"},
+		},
+		{
+			name:    "duplicate names addressed by index",
+			old:     `{"spec":{"rules":[{"name":"a","port":80},{"name":"a","port":81}]}}`,
+			new:     `{"spec":{"rules":[{"name":"a","port":80},{"name":"a","port":82}]}}`,
+			changes: []string{"replace spec.rules[1].port"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var old, new map[string]interface{}
+			if err := json.Unmarshal([]byte(testCase.old), &old); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(testCase.new), &new); err != nil {
+				t.Fatal(err)
+			}
+			changes := []string{}
+			for _, each := range Diff(old, new) {
+				changes = append(changes, each.Op+" "+each.Path)
+			}
+			if len(changes) != len(testCase.changes) {
+				t.Fatalf("changes %v, want %v", changes, testCase.changes)
+			}
+			for i := range changes {
+				if changes[i] != testCase.changes[i] {
+					t.Fatalf("changes %v, want %v", changes, testCase.changes)
+				}
+			}
+		})
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"gopkg.in/mgo.v2/bson"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"log"
+	"time"
 )
 
 const (
@@ -19,7 +20,7 @@ type K8sEvent struct {
 	TypeMeta `json:",inline" bson:",inline"`
 	// Standard object's metadata.
 	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata
-	ObjectMeta `json:"metadata" protobuf:"bytes,1,opt,name=metadata" bson:"objectMeta"`
+	ObjectMeta `json:"metadata" protobuf:"bytes,1,opt,name=metadata" bson:"metadata"`
 
 	// The object that this event is about.
 	InvolvedObject ObjectReference `json:"involvedObject" protobuf:"bytes,2,opt,name=involvedObject" bson:"involvedObject"`
@@ -84,44 +85,29 @@ type Event struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sEvent `bson:"obj" json:"obj"`
 	AgentName          string   `bson:"agent_name" json:"agent_name"`
+	// Sources occurrences counted per aggregated kubernetes event, keyed by event uid.
+	Sources map[string]int32 `bson:"sources" json:"-"`
+	// ExpireAt time after which the event is removed by ttl index.
+	ExpireAt time.Time `bson:"expire_at" json:"expire_at"`
 }
 
+// Save merges the event into the stored event of the same involved object, reason and message.
 func (e Event) Save(extra map[string]string) error {
 	e.AgentName = extra["agent_name"]
-	if e.findByNameAndNamespace().Name == "" {
-		coll := db.GetDmManager().Db.Collection(EventCollection)
-		_, err := coll.InsertOne(db.GetDmManager().Ctx, e)
-		if err != nil {
-			log.Println("[ERROR] Insert document:", err.Error())
-			return err
-		}
-	} else {
-		err := e.Update(Event{Obj: e.findByNameAndNamespace(), AgentName: e.AgentName}, e.AgentName)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	Redact(enums.EVENT, &e.Obj)
+	return e.aggregate()
 }
 
-func (e Event) Delete(agent string) error {
-	query := bson.M{
-		"$and": []bson.M{
-			{"obj.metadata.name": e.Obj.Name},
-			{"obj.metadata.namespace": e.Obj.Namespace},
-			{"agent_name": agent},
-		},
-	}
-	coll := db.GetDmManager().Db.Collection(EventCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
-	if err != nil {
-		log.Println("[ERROR]", err)
-	}
-	return err
+// Delete keeps aggregated events, kubernetes removes events after a short ttl while stored events
+// are kept until EventRetention of the company elapses. Event deletes are not applied, so no revision,
+// watch event or webhook delivery is recorded for them.
+func (e Event) Delete(extra map[string]string) error {
+	return nil
 }
 
+// Update merges the updated event into the stored event of the same involved object, reason and message.
 func (e Event) Update(oldObj interface{}, agent string) error {
-	var oldObject Ingress
+	var oldObject Event
 	body, _ := json.Marshal(oldObj)
 	errorOfUnmarshal := json.Unmarshal(body, &oldObject)
 	if errorOfUnmarshal != nil {
@@ -130,30 +116,8 @@ func (e Event) Update(oldObj interface{}, agent string) error {
 	if e.AgentName == "" {
 		e.AgentName = agent
 	}
-	filter := bson.M{
-		"$and": []bson.M{
-			{"obj.metadata.name": oldObject.Obj.Name},
-			{"obj.metadata.namespace": oldObject.Obj.Namespace},
-			{"agent_name": agent},
-		},
-	}
-	update := bson.M{
-		"$set": e,
-	}
-	upsert := true
-	after := options.After
-	opt := options.FindOneAndUpdateOptions{
-		ReturnDocument: &after,
-		Upsert:         &upsert,
-	}
-	coll := db.GetDmManager().Db.Collection(EventCollection)
-	err := coll.FindOneAndUpdate(db.GetDmManager().Ctx, filter, update, &opt)
-	if err.Err() != nil {
-		log.Println("[ERROR]", err.Err())
-		return err.Err()
-	}
-
-	return nil
+	Redact(enums.EVENT, &e.Obj)
+	return e.aggregate()
 }
 
 func (e Event) findByNameAndNamespace() K8sEvent {
@@ -310,7 +274,7 @@ func (e Event) deleteAll() error {
 	_, err := coll.DeleteMany(db.GetDmManager().Ctx, query)
 
 	if err != nil {
-		log.Println("Failed to Delete ingress [ERROR]", err)
+		log.Println("Failed to Delete event [ERROR]", err)
 	}
 	return err
 }
@@ -0,0 +1,276 @@
+package v1
+
+import (
+	"context"
+	"github.com/go-bongo/bongo"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventRetention returns how long events of a company are kept after their last occurrence.
+func EventRetention(companyId string) time.Duration {
+	if retention, ok := config.EventRetentionOverrides[companyId]; ok {
+		return retention
+	}
+	return config.EventRetention
+}
+
+// eventAggregationKey fields identifying stored events aggregated together.
+var eventAggregationKey = []string{
+	"agent_name",
+	"obj.metadata.labels.company",
+	"obj.involvedObject.kind",
+	"obj.involvedObject.namespace",
+	"obj.involvedObject.name",
+	"obj.involvedObject.uid",
+	"obj.reason",
+	"obj.message",
+}
+
+// aggregationFilter matches the stored event of the same involved object, reason and message. Most kubernetes events
+// carry no company label, their labels are stored as null.
+func (e Event) aggregationFilter() bson.M {
+	var company interface{} = e.Obj.Labels["company"]
+	if company == "" {
+		company = bson.M{"$in": []interface{}{nil, ""}}
+	}
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": e.AgentName},
+			{"obj.metadata.labels.company": company},
+			{"obj.involvedObject.kind": e.Obj.InvolvedObject.Kind},
+			{"obj.involvedObject.namespace": e.Obj.InvolvedObject.Namespace},
+			{"obj.involvedObject.name": e.Obj.InvolvedObject.Name},
+			{"obj.involvedObject.uid": e.Obj.InvolvedObject.UID},
+			{"obj.reason": e.Obj.Reason},
+			{"obj.message": e.Obj.Message},
+		},
+	}
+}
+
+// sourceKey identifies a kubernetes event among aggregated ones.
+func (e K8sEvent) sourceKey() string {
+	if e.UID != "" {
+		return string(e.UID)
+	}
+	return strings.ReplaceAll(e.Namespace+"/"+e.Name, ".", "_")
+}
+
+// occurrences returns number of times the kubernetes event has occurred.
+func (e K8sEvent) occurrences() int32 {
+	count := e.Count
+	if e.Series != nil && e.Series.Count > count {
+		count = e.Series.Count
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// firstSeen returns time of the first occurrence, zero if unknown.
+func (e K8sEvent) firstSeen() time.Time {
+	first := e.FirstTimestamp.Time
+	if !e.EventTime.IsZero() && (first.IsZero() || e.EventTime.Time.Before(first)) {
+		first = e.EventTime.Time
+	}
+	return first
+}
+
+// lastSeen returns time of the latest occurrence, zero if unknown.
+func (e K8sEvent) lastSeen() time.Time {
+	last := e.LastTimestamp.Time
+	for _, each := range []time.Time{e.EventTime.Time, e.firstSeen()} {
+		if each.After(last) {
+			last = each
+		}
+	}
+	if e.Series != nil && e.Series.LastObservedTime.Time.After(last) {
+		last = e.Series.LastObservedTime.Time
+	}
+	return last
+}
+
+// mergeEvents merges a received event into the stored event of the same involved object, reason and message.
+// Occurrences are counted once per kubernetes event, so repeated updates of the same event do not inflate the count.
+func mergeEvents(stored *Event, received Event) Event {
+	sources := map[string]int32{}
+	merged := received
+	first, last := received.Obj.firstSeen(), received.Obj.lastSeen()
+	hasSeries := received.Obj.Series != nil
+	if stored != nil {
+		for key, count := range stored.Sources {
+			sources[key] = count
+		}
+		if len(sources) == 0 {
+			sources[stored.Obj.sourceKey()] = stored.Obj.occurrences()
+		}
+		storedFirst, storedLast := stored.Obj.firstSeen(), stored.Obj.lastSeen()
+		if !storedFirst.IsZero() && (first.IsZero() || storedFirst.Before(first)) {
+			first = storedFirst
+		}
+		if storedLast.After(last) {
+			// received event is older than the stored one, keep the latest state
+			merged.Obj = stored.Obj
+			last = storedLast
+		}
+		hasSeries = hasSeries || stored.Obj.Series != nil
+	}
+	key := received.Obj.sourceKey()
+	if count := received.Obj.occurrences(); count > sources[key] {
+		sources[key] = count
+	}
+	var total int32
+	for _, count := range sources {
+		total += count
+	}
+	merged.Sources = sources
+	merged.Obj.Count = total
+	merged.Obj.FirstTimestamp = metav1.Time{Time: first}
+	merged.Obj.LastTimestamp = metav1.Time{Time: last}
+	if hasSeries {
+		merged.Obj.Series = &EventSeries{Count: total, LastObservedTime: metav1.MicroTime{Time: last}}
+	}
+	return merged
+}
+
+// aggregate upserts the event merged with the stored event of the same involved object, reason and message. An insert
+// racing with a concurrent save of the same event violates the unique aggregation index and is merged instead.
+func (e Event) aggregate() error {
+	err := e.aggregateOnce()
+	if mongo.IsDuplicateKeyError(err) {
+		err = e.aggregateOnce()
+	}
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+	return err
+}
+
+func (e Event) aggregateOnce() error {
+	coll := db.GetDmManager().Db.Collection(EventCollection)
+	filter := e.aggregationFilter()
+	var stored *Event
+	temp := new(Event)
+	err := coll.FindOne(db.GetDmManager().Ctx, filter).Decode(temp)
+	if err == nil {
+		stored = temp
+	} else if err != mongo.ErrNoDocuments {
+		return err
+	}
+	merged := mergeEvents(stored, e)
+	merged.ExpireAt = time.Now().UTC().Add(EventRetention(e.Obj.Labels["company"]))
+	merged.DocumentBase = bongo.DocumentBase{}
+	if stored != nil {
+		_, err = coll.ReplaceOne(db.GetDmManager().Ctx, filter, merged)
+	} else {
+		_, err = coll.InsertOne(db.GetDmManager().Ctx, merged)
+	}
+	return err
+}
+
+// mergeDuplicateEvents merges stored events sharing an aggregation key, left by saves that did not find each other.
+// Each duplicate holds occurrences of a single kubernetes event, so folding them with mergeEvents keeps the count.
+func mergeDuplicateEvents() {
+	coll := db.GetDmManager().Db.Collection(EventCollection)
+	key := bson.M{}
+	for i, each := range eventAggregationKey {
+		key["k"+strconv.Itoa(i)] = "$" + each
+	}
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": key, "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+	curser, err := coll.Aggregate(db.GetDmManager().Ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Println("[ERROR] Failed to find duplicate events:", err.Error())
+		return
+	}
+	defer curser.Close(context.TODO())
+	for curser.Next(context.TODO()) {
+		var group struct {
+			Ids []interface{} `bson:"ids"`
+		}
+		if err := curser.Decode(&group); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		var merged *Event
+		for _, id := range group.Ids {
+			event := new(Event)
+			if err := coll.FindOne(db.GetDmManager().Ctx, bson.M{"_id": id}).Decode(event); err != nil {
+				log.Println("[ERROR]", err)
+				continue
+			}
+			if merged == nil {
+				merged = event
+				continue
+			}
+			next := mergeEvents(merged, *event)
+			merged = &next
+		}
+		if merged == nil {
+			continue
+		}
+		merged.ExpireAt = time.Now().UTC().Add(EventRetention(merged.Obj.Labels["company"]))
+		merged.DocumentBase = bongo.DocumentBase{}
+		if _, err := coll.ReplaceOne(db.GetDmManager().Ctx, bson.M{"_id": group.Ids[0]}, merged); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		if _, err := coll.DeleteMany(db.GetDmManager().Ctx, bson.M{"_id": bson.M{"$in": group.Ids[1:]}}); err != nil {
+			log.Println("[ERROR]", err)
+		}
+	}
+}
+
+// EnsureEventIndexes creates aggregation and ttl indexes of events and migrates events stored before aggregation,
+// moving their metadata from obj.objectMeta to obj.metadata and setting their expiry. Duplicates of an aggregation key
+// are merged before its unique index is created.
+func EnsureEventIndexes() {
+	coll := db.GetDmManager().Db.Collection(EventCollection)
+	_, err := coll.Indexes().CreateMany(db.GetDmManager().Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expire_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{
+				{Key: "agent_name", Value: 1},
+				{Key: "obj.involvedObject.uid", Value: 1},
+				{Key: "obj.reason", Value: 1},
+			},
+		},
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create event indexes:", err.Error())
+	}
+	_, err = coll.UpdateMany(db.GetDmManager().Ctx, bson.M{"obj.objectMeta": bson.M{"$exists": true}}, bson.M{"$rename": bson.M{"obj.objectMeta": "obj.metadata"}})
+	if err != nil {
+		log.Println("[ERROR] Failed to migrate event metadata:", err.Error())
+	}
+	_, err = coll.UpdateMany(db.GetDmManager().Ctx, bson.M{"expire_at": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"expire_at": time.Now().UTC().Add(config.EventRetention)}})
+	if err != nil {
+		log.Println("[ERROR] Failed to set expiry of events:", err.Error())
+	}
+	mergeDuplicateEvents()
+	keys := bson.D{}
+	for _, each := range eventAggregationKey {
+		keys = append(keys, bson.E{Key: each, Value: 1})
+	}
+	_, err = coll.Indexes().CreateOne(db.GetDmManager().Ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true).SetName("event_aggregation_key"),
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create event aggregation index:", err.Error())
+	}
+}
@@ -0,0 +1,285 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"sort"
+	"strings"
+)
+
+const (
+	// GraphFormatJSON nodes and edges as json.
+	GraphFormatJSON = "json"
+	// GraphFormatDOT graphviz dot.
+	GraphFormatDOT = "dot"
+)
+
+const (
+	// EdgeOwns owner to dependent, from owner references.
+	EdgeOwns = "owns"
+	// EdgeSelects service to pod matching its selector.
+	EdgeSelects = "selects"
+	// EdgeMounts pod to configmap, secret or persistent volume claim it uses.
+	EdgeMounts = "mounts"
+	// EdgeRoutesTo ingress to backend service.
+	EdgeRoutesTo = "routes-to"
+	// EdgeScheduledOn pod to node.
+	EdgeScheduledOn = "scheduled-on"
+	// EdgeBindsTo persistent volume claim to persistent volume.
+	EdgeBindsTo = "binds-to"
+)
+
+// ErrUnknownGraphFormat returned for unsupported graph export formats.
+var ErrUnknownGraphFormat = errors.New("unknown graph format, supported: json, dot")
+
+// graphTypes resource types exported as graph nodes.
+var graphTypes = []enums.RESOURCE_TYPE{
+	enums.DEPLOYMENT, enums.REPLICASET, enums.STATEFULSET, enums.DAEMONSET, enums.POD, enums.SERVICE, enums.INGRESS,
+	enums.CONFIG_MAP, enums.SECRET, enums.PERSISTENT_VOLUME_CLAIM, enums.PERSISTENT_VOLUME, enums.NODE,
+}
+
+// GraphNode stored object of a dependency graph.
+type GraphNode struct {
+	Id        string `json:"id"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Missing set for objects referenced by others but not stored.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// GraphEdge typed relation between two nodes.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// Graph dependency graph of stored objects.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+func graphNodeId(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + "/" + name
+	}
+	return kind + "/" + namespace + "/" + name
+}
+
+type graphBuilder struct {
+	nodes map[string]GraphNode
+	edges map[GraphEdge]bool
+	// uids node id by uid, used to resolve owner references
+	uids map[UID]string
+	// cluster nodes of cluster scoped objects, added to a namespace graph only if referenced
+	cluster map[string]GraphNode
+}
+
+func (b *graphBuilder) addNode(kind string, meta ObjectMeta, cluster bool) {
+	node := GraphNode{Id: graphNodeId(kind, meta.Namespace, meta.Name), Kind: kind, Namespace: meta.Namespace, Name: meta.Name}
+	b.uids[meta.UID] = node.Id
+	if cluster {
+		b.cluster[node.Id] = node
+		return
+	}
+	b.nodes[node.Id] = node
+}
+
+// addEdge adds an edge, target is added as missing node if it is not stored.
+func (b *graphBuilder) addEdge(from, edgeType, kind, namespace, name string) {
+	if name == "" {
+		return
+	}
+	to := graphNodeId(kind, namespace, name)
+	if _, ok := b.nodes[to]; !ok {
+		if node, ok := b.cluster[to]; ok {
+			b.nodes[to] = node
+		} else {
+			b.nodes[to] = GraphNode{Id: to, Kind: kind, Namespace: namespace, Name: name, Missing: true}
+		}
+	}
+	b.edges[GraphEdge{From: from, To: to, Type: edgeType}] = true
+}
+
+// BuildGraph returns dependency graph of stored objects of an agent in a namespace, or of the whole cluster if namespace
+// is empty. Cluster scoped objects are part of a namespace graph only if objects of the namespace reference them.
+func BuildGraph(companyId, agent, namespace string) (Graph, error) {
+	builder := &graphBuilder{
+		nodes:   make(map[string]GraphNode),
+		edges:   make(map[GraphEdge]bool),
+		uids:    make(map[UID]string),
+		cluster: make(map[string]GraphNode),
+	}
+	objects := []KubeObject{}
+	for _, object := range graphTypes {
+		descriptor, _ := GetResourceDescriptor(object)
+		kubeObjects, err := findLiveObjects(object, companyId, agent, namespace)
+		if err != nil {
+			return Graph{}, err
+		}
+		for _, kubeObject := range kubeObjects {
+			meta, ok := graphObjectMeta(kubeObject)
+			if !ok {
+				continue
+			}
+			builder.addNode(descriptor.Kind, meta, !descriptor.Namespaced && namespace != "")
+			objects = append(objects, kubeObject)
+		}
+	}
+	pods := []K8sPod{}
+	for _, kubeObject := range objects {
+		if pod, ok := kubeObject.(*Pod); ok {
+			pods = append(pods, pod.Obj)
+		}
+	}
+	for _, kubeObject := range objects {
+		meta, _ := graphObjectMeta(kubeObject)
+		switch object := kubeObject.(type) {
+		case *Pod:
+			from := graphNodeId("Pod", meta.Namespace, meta.Name)
+			builder.addEdge(from, EdgeScheduledOn, "Node", "", object.Obj.Spec.NodeName)
+			for _, each := range object.Obj.configReferences() {
+				builder.addEdge(from, EdgeMounts, each.Kind, meta.Namespace, each.Name)
+			}
+			for _, volume := range object.Obj.Spec.Volumes {
+				if volume.PersistentVolumeClaim != nil {
+					builder.addEdge(from, EdgeMounts, "PersistentVolumeClaim", meta.Namespace, volume.PersistentVolumeClaim.ClaimName)
+				}
+			}
+		case *Service:
+			from := graphNodeId("Service", meta.Namespace, meta.Name)
+			for _, pod := range pods {
+				if object.Obj.selects(pod) {
+					builder.addEdge(from, EdgeSelects, "Pod", pod.Namespace, pod.Name)
+				}
+			}
+		case *Ingress:
+			from := graphNodeId("Ingress", meta.Namespace, meta.Name)
+			if object.Obj.Spec.Backend != nil {
+				builder.addEdge(from, EdgeRoutesTo, "Service", meta.Namespace, object.Obj.Spec.Backend.ServiceName)
+			}
+			for _, rule := range object.Obj.Spec.Rules {
+				if rule.HTTP == nil {
+					continue
+				}
+				for _, path := range rule.HTTP.Paths {
+					builder.addEdge(from, EdgeRoutesTo, "Service", meta.Namespace, path.Backend.ServiceName)
+				}
+			}
+		case *PersistentVolumeClaim:
+			builder.addEdge(graphNodeId("PersistentVolumeClaim", meta.Namespace, meta.Name), EdgeBindsTo, "PersistentVolume", "", object.Obj.Spec.VolumeName)
+		}
+	}
+	for _, kubeObject := range objects {
+		meta, _ := graphObjectMeta(kubeObject)
+		to, ok := builder.uids[meta.UID]
+		if !ok {
+			continue
+		}
+		if _, ok := builder.nodes[to]; !ok {
+			continue
+		}
+		for _, owner := range meta.OwnerReferences {
+			if from, ok := builder.uids[owner.UID]; ok {
+				if _, ok := builder.nodes[from]; ok {
+					builder.edges[GraphEdge{From: from, To: to, Type: EdgeOwns}] = true
+				}
+			}
+		}
+	}
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, node := range builder.nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for edge := range builder.edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Id < graph.Nodes[j].Id })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		if graph.Edges[i].To != graph.Edges[j].To {
+			return graph.Edges[i].To < graph.Edges[j].To
+		}
+		return graph.Edges[i].Type < graph.Edges[j].Type
+	})
+	return graph, nil
+}
+
+// graphObjectMeta returns metadata of a stored object exported as graph node.
+func graphObjectMeta(kubeObject KubeObject) (ObjectMeta, bool) {
+	switch object := kubeObject.(type) {
+	case *Deployment:
+		return object.Obj.ObjectMeta, true
+	case *ReplicaSet:
+		return object.Obj.ObjectMeta, true
+	case *StatefulSet:
+		return object.Obj.ObjectMeta, true
+	case *DaemonSet:
+		return object.Obj.ObjectMeta, true
+	case *Pod:
+		return object.Obj.ObjectMeta, true
+	case *Service:
+		return object.Obj.ObjectMeta, true
+	case *Ingress:
+		return object.Obj.ObjectMeta, true
+	case *ConfigMap:
+		return object.Obj.ObjectMeta, true
+	case *Secret:
+		return object.Obj.ObjectMeta, true
+	case *PersistentVolumeClaim:
+		return object.Obj.ObjectMeta, true
+	case *PersistentVolume:
+		return object.Obj.ObjectMeta, true
+	case *Node:
+		return object.Obj.ObjectMeta, true
+	}
+	return ObjectMeta{}, false
+}
+
+// dotShapes graphviz node shape by kind.
+var dotShapes = map[string]string{
+	"Deployment":            "box3d",
+	"StatefulSet":           "box3d",
+	"DaemonSet":             "box3d",
+	"ReplicaSet":            "box",
+	"Pod":                   "ellipse",
+	"Service":               "hexagon",
+	"Ingress":               "invhouse",
+	"ConfigMap":             "note",
+	"Secret":                "note",
+	"PersistentVolumeClaim": "cylinder",
+	"PersistentVolume":      "cylinder",
+	"Node":                  "component",
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+// DOT returns the graph in graphviz dot format.
+func (g Graph) DOT(name string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %s {\n\trankdir=LR;\n", dotQuote(name))
+	for _, node := range g.Nodes {
+		shape, ok := dotShapes[node.Kind]
+		if !ok {
+			shape = "box"
+		}
+		style := ""
+		if node.Missing {
+			style = `, style=dashed, color=red`
+		}
+		fmt.Fprintf(&buf, "\t%s [label=%s, shape=%s%s];\n", dotQuote(node.Id), dotQuote(node.Kind+"\n"+node.Name), shape, style)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&buf, "\t%s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Type))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
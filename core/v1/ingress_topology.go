@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sort"
+)
+
+const (
+	// RouteServiceNotFound backend service of the route is not stored.
+	RouteServiceNotFound = "SERVICE_NOT_FOUND"
+	// RouteUnknownPort backend service does not expose the port of the route.
+	RouteUnknownPort = "UNKNOWN_SERVICE_PORT"
+	// RouteTLSSecretNotFound tls secret of the host is not stored.
+	RouteTLSSecretNotFound = "TLS_SECRET_NOT_FOUND"
+	// RouteNoReadyEndpoints backend service selects no ready pod.
+	RouteNoReadyEndpoints = "NO_READY_ENDPOINTS"
+)
+
+// IngressRoute external host and path routed by an ingress to a service port and the pods behind it.
+type IngressRoute struct {
+	Ingress   string `json:"ingress"`
+	Namespace string `json:"namespace"`
+	// Host empty if the rule matches every host.
+	Host string `json:"host"`
+	Path string `json:"path"`
+	// Default route of requests matching no rule.
+	Default     bool              `json:"default,omitempty"`
+	TLS         bool              `json:"tls"`
+	TLSSecret   string            `json:"tls_secret,omitempty"`
+	Service     string            `json:"service"`
+	ServicePort string            `json:"service_port"`
+	Port        *EndpointPort     `json:"port,omitempty"`
+	Endpoints   []ServiceEndpoint `json:"endpoints"`
+	NotReady    []ServiceEndpoint `json:"not_ready"`
+	Problems    []string          `json:"problems"`
+}
+
+// servicePort returns port of the service referenced by number or name.
+func (s K8sService) servicePort(port intstr.IntOrString) (ServicePort, bool) {
+	for _, each := range s.Spec.Ports {
+		if (port.Type == intstr.String && each.Name == port.StrVal) || (port.Type == intstr.Int && each.Port == port.IntVal) {
+			return each, true
+		}
+	}
+	return ServicePort{}, false
+}
+
+// tlsSecret returns secret terminating tls of a host, if any. TLS entries without hosts apply to every host.
+func (i K8sIngress) tlsSecret(host string) (string, bool) {
+	for _, each := range i.Spec.TLS {
+		if len(each.Hosts) == 0 {
+			return each.SecretName, true
+		}
+		for _, tlsHost := range each.Hosts {
+			if tlsHost == host {
+				return each.SecretName, true
+			}
+		}
+	}
+	return "", false
+}
+
+// routeEndpoints keeps ports of endpoints resolved from the routed service port.
+func routeEndpoints(endpoints []ServiceEndpoint, port ServicePort) []ServiceEndpoint {
+	result := []ServiceEndpoint{}
+	for _, each := range endpoints {
+		ports := []EndpointPort{}
+		for _, endpointPort := range each.Ports {
+			if endpointPort.Port == port.Port {
+				ports = append(ports, endpointPort)
+			}
+		}
+		each.Ports = ports
+		result = append(result, each)
+	}
+	return result
+}
+
+// resolveIngressRoute resolves backend of a route to its service port and pods, flagging broken links.
+func resolveIngressRoute(route IngressRoute, backend IngressBackend, services map[ObjectName]K8sService, secrets map[ObjectName]bool, pods []K8sPod) IngressRoute {
+	route.Service = backend.ServiceName
+	route.ServicePort = backend.ServicePort.String()
+	route.Endpoints = []ServiceEndpoint{}
+	route.NotReady = []ServiceEndpoint{}
+	route.Problems = []string{}
+	if route.TLS && route.TLSSecret != "" && !secrets[ObjectName{Namespace: route.Namespace, Name: route.TLSSecret}] {
+		route.Problems = append(route.Problems, RouteTLSSecretNotFound)
+	}
+	service, ok := services[ObjectName{Namespace: route.Namespace, Name: backend.ServiceName}]
+	if !ok {
+		route.Problems = append(route.Problems, RouteServiceNotFound)
+		return route
+	}
+	port, ok := service.servicePort(backend.ServicePort)
+	if !ok {
+		route.Problems = append(route.Problems, RouteUnknownPort)
+		return route
+	}
+	// a named target port may resolve to different container ports, endpoints carry the resolved ones
+	route.Port = &EndpointPort{Name: port.Name, Port: port.Port, Protocol: port.Protocol}
+	switch {
+	case port.TargetPort.Type == intstr.String && port.TargetPort.StrVal != "":
+		route.Port.TargetPortName = port.TargetPort.StrVal
+	case port.TargetPort.IntVal != 0:
+		route.Port.TargetPort = port.TargetPort.IntVal
+	default:
+		route.Port.TargetPort = port.Port
+	}
+	endpoints := service.endpoints(pods)
+	route.Endpoints = routeEndpoints(endpoints.Endpoints, port)
+	route.NotReady = routeEndpoints(endpoints.NotReady, port)
+	if len(route.Endpoints) == 0 && len(service.Spec.Selector) > 0 {
+		route.Problems = append(route.Problems, RouteNoReadyEndpoints)
+	}
+	return route
+}
+
+// FindIngressTopology returns every host and path routed by stored ingresses of an agent, optionally in a namespace,
+// along with backend services and pods behind them.
+func FindIngressTopology(companyId, agent, namespace string) ([]IngressRoute, error) {
+	kubeObjects, err := findLiveObjects(enums.INGRESS, companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	serviceList, err := findServices(companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[ObjectName]K8sService)
+	for _, each := range serviceList {
+		services[ObjectName{Namespace: each.Namespace, Name: each.Name}] = each
+	}
+	secretObjects, err := findLiveObjects(enums.SECRET, companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[ObjectName]bool)
+	for _, each := range secretObjects {
+		secret := each.(*Secret).Obj
+		secrets[ObjectName{Namespace: secret.Namespace, Name: secret.Name}] = true
+	}
+	pods, err := findPods(companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	routes := []IngressRoute{}
+	for _, each := range kubeObjects {
+		ingress := each.(*Ingress).Obj
+		if ingress.Spec.Backend != nil {
+			route := IngressRoute{Ingress: ingress.Name, Namespace: ingress.Namespace, Default: true}
+			routes = append(routes, resolveIngressRoute(route, *ingress.Spec.Backend, services, secrets, pods))
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				route := IngressRoute{Ingress: ingress.Name, Namespace: ingress.Namespace, Host: rule.Host, Path: path.Path}
+				route.TLSSecret, route.TLS = ingress.tlsSecret(rule.Host)
+				routes = append(routes, resolveIngressRoute(route, path.Backend, services, secrets, pods))
+			}
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, nil
+}
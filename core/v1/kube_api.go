@@ -0,0 +1,226 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidContinueToken returned when a list continue token can not be parsed.
+var ErrInvalidContinueToken = errors.New("invalid continue token")
+
+// kubeVerbs verbs served by the kubernetes compatible api.
+var kubeVerbs = []string{"get", "list", "watch"}
+
+// APIVersions kubernetes core api discovery document.
+type APIVersions struct {
+	TypeMeta `json:",inline"`
+	Versions []string `json:"versions"`
+}
+
+// GroupVersionForDiscovery group version of an api group.
+type GroupVersionForDiscovery struct {
+	GroupVersion string `json:"groupVersion"`
+	Version      string `json:"version"`
+}
+
+// APIGroup kubernetes api group discovery document.
+type APIGroup struct {
+	TypeMeta         `json:",inline"`
+	Name             string                     `json:"name"`
+	Versions         []GroupVersionForDiscovery `json:"versions"`
+	PreferredVersion GroupVersionForDiscovery   `json:"preferredVersion"`
+}
+
+// APIGroupList kubernetes api groups discovery document.
+type APIGroupList struct {
+	TypeMeta `json:",inline"`
+	Groups   []APIGroup `json:"groups"`
+}
+
+// APIResource resource of an api group version.
+type APIResource struct {
+	Name         string   `json:"name"`
+	SingularName string   `json:"singularName"`
+	Namespaced   bool     `json:"namespaced"`
+	Kind         string   `json:"kind"`
+	Verbs        []string `json:"verbs"`
+}
+
+// APIResourceList kubernetes resources discovery document of a group version.
+type APIResourceList struct {
+	TypeMeta     `json:",inline"`
+	GroupVersion string        `json:"groupVersion"`
+	Resources    []APIResource `json:"resources"`
+}
+
+// KubeList kubernetes List of stored objects.
+type KubeList struct {
+	TypeMeta `json:",inline"`
+	ListMeta `json:"metadata"`
+	Items    []json.RawMessage `json:"items"`
+}
+
+// groupVersion splits api version into group and version, group is empty for core api.
+func groupVersion(apiVersion string) (string, string) {
+	if i := strings.Index(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i], apiVersion[i+1:]
+	}
+	return "", apiVersion
+}
+
+// GetResourceDescriptorByResource returns descriptor of a plural resource name in an api group version.
+func GetResourceDescriptorByResource(apiVersion, resource string) (ResourceDescriptor, bool) {
+	for _, each := range Resources {
+		if each.APIVersion == apiVersion && each.Resource == resource {
+			return each, true
+		}
+	}
+	return ResourceDescriptor{}, false
+}
+
+// GetAPIVersions returns discovery document of /api.
+func GetAPIVersions() APIVersions {
+	return APIVersions{
+		TypeMeta: TypeMeta{Kind: "APIVersions"},
+		Versions: []string{"v1"},
+	}
+}
+
+// GetAPIGroupList returns discovery document of /apis.
+func GetAPIGroupList() APIGroupList {
+	groups := map[string]*APIGroup{}
+	var names []string
+	for _, each := range Resources {
+		group, version := groupVersion(each.APIVersion)
+		if group == "" {
+			continue
+		}
+		apiGroup, ok := groups[group]
+		if !ok {
+			apiGroup = &APIGroup{Name: group}
+			groups[group] = apiGroup
+			names = append(names, group)
+		}
+		exists := false
+		for _, v := range apiGroup.Versions {
+			exists = exists || v.Version == version
+		}
+		if !exists {
+			gv := GroupVersionForDiscovery{GroupVersion: each.APIVersion, Version: version}
+			apiGroup.Versions = append(apiGroup.Versions, gv)
+			apiGroup.PreferredVersion = gv
+		}
+	}
+	sort.Strings(names)
+	list := APIGroupList{
+		TypeMeta: TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+		Groups:   []APIGroup{},
+	}
+	for _, name := range names {
+		list.Groups = append(list.Groups, *groups[name])
+	}
+	return list
+}
+
+// GetAPIGroup returns discovery document of /apis/{group}.
+func GetAPIGroup(group string) (APIGroup, bool) {
+	for _, each := range GetAPIGroupList().Groups {
+		if each.Name == group {
+			each.TypeMeta = TypeMeta{Kind: "APIGroup", APIVersion: "v1"}
+			return each, true
+		}
+	}
+	return APIGroup{}, false
+}
+
+// GetAPIResourceList returns discovery document of an api group version, e.g. /api/v1 or /apis/apps/v1.
+func GetAPIResourceList(apiVersion string) (APIResourceList, bool) {
+	list := APIResourceList{
+		TypeMeta:     TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: apiVersion,
+		Resources:    []APIResource{},
+	}
+	for _, each := range Resources {
+		if each.APIVersion != apiVersion {
+			continue
+		}
+		list.Resources = append(list.Resources, APIResource{
+			Name:         each.Resource,
+			SingularName: strings.ToLower(each.Kind),
+			Namespaced:   each.Namespaced,
+			Kind:         each.Kind,
+			Verbs:        kubeVerbs,
+		})
+	}
+	return list, len(list.Resources) > 0
+}
+
+// ToKubeObject returns stored object in kubernetes format, with kind and apiVersion set.
+func ToKubeObject(descriptor ResourceDescriptor, obj json.RawMessage) (json.RawMessage, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal(obj, &object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		object = map[string]interface{}{}
+	}
+	object["kind"] = descriptor.Kind
+	object["apiVersion"] = descriptor.APIVersion
+	return json.Marshal(object)
+}
+
+// ListKubeObjects returns stored objects of the query as a kubernetes List. Every matching object is returned
+// unless limit is set, in which case continueToken, returned in metadata.continue, pages through the result.
+func ListKubeObjects(q ResourceQuery, limit int64, continueToken string) (KubeList, error) {
+	descriptor, ok := GetResourceDescriptor(q.Type)
+	if !ok {
+		return KubeList{}, ErrUnknownResource
+	}
+	list := KubeList{
+		TypeMeta: TypeMeta{Kind: descriptor.Kind + "List", APIVersion: descriptor.APIVersion},
+		ListMeta: ListMeta{ResourceVersion: strconv.FormatUint(getKubeWatchHub().revision(), 10)},
+		Items:    []json.RawMessage{},
+	}
+	q.Page = 0
+	q.Limit = MaxPageLimit
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	if limit > 0 {
+		q.Limit = limit
+		if continueToken != "" {
+			page, err := strconv.ParseInt(continueToken, 10, 64)
+			if err != nil || page < 0 {
+				return KubeList{}, ErrInvalidContinueToken
+			}
+			q.Page = page
+		}
+	}
+	for {
+		objects, total, err := FindResources(q)
+		if err != nil {
+			return KubeList{}, err
+		}
+		for _, each := range objects {
+			item, err := ToKubeObject(descriptor, each.Obj)
+			if err != nil {
+				return KubeList{}, err
+			}
+			list.Items = append(list.Items, item)
+		}
+		more := (q.Page+1)*q.Limit < total
+		if limit > 0 {
+			if more {
+				list.Continue = strconv.FormatInt(q.Page+1, 10)
+			}
+			return list, nil
+		}
+		if !more || len(objects) == 0 {
+			return list, nil
+		}
+		q.Page++
+	}
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"log"
+	"time"
+)
+
+// AppliedKubeEvent kube event successfully applied to the store.
+type AppliedKubeEvent struct {
+	Type      enums.RESOURCE_TYPE
+	Command   enums.Command
+	Offset    int
+	AgentName string
+	// Object stored wrapper of the applied object, the new object in case of UPDATE.
+	Object KubeObject
+	// OldObject stored wrapper of the replaced object, set in case of UPDATE.
+	OldObject KubeObject
+	// CompanyId, Meta, Obj and Changes are filled from the redacted objects before hooks run.
+	CompanyId string
+	Meta      ObjectMeta
+	Obj       map[string]interface{}
+	Changes   []FieldChange
+	AppliedAt time.Time
+}
+
+// redactedObject returns redacted kube object of a stored wrapper in its json form.
+func redactedObject(object enums.RESOURCE_TYPE, kubeObject KubeObject) (map[string]interface{}, error) {
+	data, err := json.Marshal(kubeObject)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Obj map[string]interface{} `json:"obj"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	if body.Obj == nil {
+		body.Obj = map[string]interface{}{}
+	}
+	Redact(object, &body.Obj)
+	return body.Obj, nil
+}
+
+// OnKubeEventApplied runs post processing of an applied kube event, records revision history along with field changes of updates, indexes objects stamped by pipelines and notifies watchers and webhooks.
+// Rollout results of workloads are posted back to the pipeline. Deletion of a namespace cascades to objects of the namespace.
+func OnKubeEventApplied(event AppliedKubeEvent) {
+	obj, err := redactedObject(event.Type, event.Object)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return
+	}
+	event.Obj = obj
+	event.Meta = GetObjectMeta(obj)
+	event.CompanyId = event.Meta.Labels["company"]
+	if event.Command == enums.UPDATE && event.OldObject != nil {
+		old, err := redactedObject(event.Type, event.OldObject)
+		if err != nil {
+			log.Println("[ERROR]", err)
+		} else {
+			event.Changes = Diff(old, obj)
+		}
+	}
+	if event.AppliedAt.IsZero() {
+		event.AppliedAt = time.Now().UTC()
+	}
+	recordRevision(event)
+	indexPipelineObject(event)
+	publishKubeEvent(event)
+	dispatchWebhookEvents(event)
+	notifyRolloutResult(event)
+	if event.Type == enums.NAMESPACE && event.Command == enums.DELETE {
+		cascadeNamespaceDeletion(event)
+	}
+}
@@ -1,15 +1,87 @@
 package v1
 
 import (
+	"encoding/json"
 	"github.com/klovercloud-ci-cd/light-house-command/enums"
 )
 
 type KubeObject interface {
 	Save(extra map[string]string) error
-	Delete(agent string) error
+	Delete(extra map[string]string) error
 	Update(oldObj interface{}, agent string) error
 }
 
+// ResourceDescriptor describes how a resource type is stored.
+type ResourceDescriptor struct {
+	Type       enums.RESOURCE_TYPE
+	Kind       string
+	APIVersion string
+	// Resource plural resource name used in kubernetes api paths.
+	Resource   string
+	Collection string
+	Namespaced bool
+}
+
+// Resources all stored resource types.
+var Resources = []ResourceDescriptor{
+	{Type: enums.CERTIFICATE, Kind: "Certificate", APIVersion: "cert-manager.io/v1", Resource: "certificates", Collection: CertificateCollection, Namespaced: true},
+	{Type: enums.CLUSTER_ROLE, Kind: "ClusterRole", APIVersion: "rbac.authorization.k8s.io/v1", Resource: "clusterroles", Collection: ClusterRoleCollection},
+	{Type: enums.CLUSTER_ROLE_BINDGING, Kind: "ClusterRoleBinding", APIVersion: "rbac.authorization.k8s.io/v1", Resource: "clusterrolebindings", Collection: ClusterRoleBindingCollection},
+	{Type: enums.CONFIG_MAP, Kind: "ConfigMap", APIVersion: "v1", Resource: "configmaps", Collection: ConfigmapCollection, Namespaced: true},
+	{Type: enums.DAEMONSET, Kind: "DaemonSet", APIVersion: "apps/v1", Resource: "daemonsets", Collection: DaemonSetCollection, Namespaced: true},
+	{Type: enums.DEPLOYMENT, Kind: "Deployment", APIVersion: "apps/v1", Resource: "deployments", Collection: DeploymentCollection, Namespaced: true},
+	{Type: enums.EVENT, Kind: "Event", APIVersion: "v1", Resource: "events", Collection: EventCollection, Namespaced: true},
+	{Type: enums.INGRESS, Kind: "Ingress", APIVersion: "extensions/v1beta1", Resource: "ingresses", Collection: IngressCollection, Namespaced: true},
+	{Type: enums.NAMESPACE, Kind: "Namespace", APIVersion: "v1", Resource: "namespaces", Collection: NamespaceCollection},
+	{Type: enums.NETWORK_POLICY, Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1", Resource: "networkpolicies", Collection: NetworkPolicyCollection, Namespaced: true},
+	{Type: enums.NODE, Kind: "Node", APIVersion: "v1", Resource: "nodes", Collection: NodeCollection},
+	{Type: enums.PERSISTENT_VOLUME, Kind: "PersistentVolume", APIVersion: "v1", Resource: "persistentvolumes", Collection: PVCollection},
+	{Type: enums.PERSISTENT_VOLUME_CLAIM, Kind: "PersistentVolumeClaim", APIVersion: "v1", Resource: "persistentvolumeclaims", Collection: PVCCollection, Namespaced: true},
+	{Type: enums.POD, Kind: "Pod", APIVersion: "v1", Resource: "pods", Collection: PodCollection, Namespaced: true},
+	{Type: enums.REPLICASET, Kind: "ReplicaSet", APIVersion: "apps/v1", Resource: "replicasets", Collection: ReplicaSetCollection, Namespaced: true},
+	{Type: enums.ROLE, Kind: "Role", APIVersion: "rbac.authorization.k8s.io/v1", Resource: "roles", Collection: RoleCollection, Namespaced: true},
+	{Type: enums.ROLE_BINDING, Kind: "RoleBinding", APIVersion: "rbac.authorization.k8s.io/v1", Resource: "rolebindings", Collection: RoleBindingCollection, Namespaced: true},
+	{Type: enums.SECRET, Kind: "Secret", APIVersion: "v1", Resource: "secrets", Collection: SecretCollection, Namespaced: true},
+	{Type: enums.SERVICE, Kind: "Service", APIVersion: "v1", Resource: "services", Collection: ServiceCollection, Namespaced: true},
+	{Type: enums.SERVICE_ACCOUNT, Kind: "ServiceAccount", APIVersion: "v1", Resource: "serviceaccounts", Collection: ServiceAccountCollection, Namespaced: true},
+	{Type: enums.STATEFULSET, Kind: "StatefulSet", APIVersion: "apps/v1", Resource: "statefulsets", Collection: StatefulSetCollection, Namespaced: true},
+}
+
+// GetResourceDescriptor returns descriptor of a resource type.
+func GetResourceDescriptor(object enums.RESOURCE_TYPE) (ResourceDescriptor, bool) {
+	for _, each := range Resources {
+		if each.Type == object {
+			return each, true
+		}
+	}
+	return ResourceDescriptor{}, false
+}
+
+// Object returns the kube object carried by the message, the new object in case of UPDATE.
+func (m KubeEventMessage) Object() interface{} {
+	if m.Header.Command == enums.UPDATE {
+		if body, ok := m.Body.(map[string]interface{}); ok {
+			return body["new_k8s_obj"]
+		}
+	}
+	return m.Body
+}
+
+// GetObjectMeta returns metadata of a raw kube object.
+func GetObjectMeta(obj interface{}) ObjectMeta {
+	var temp struct {
+		ObjectMeta `json:"metadata"`
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return ObjectMeta{}
+	}
+	if err = json.Unmarshal(data, &temp); err != nil {
+		return ObjectMeta{}
+	}
+	return temp.ObjectMeta
+}
+
 func GetObject(object enums.RESOURCE_TYPE) KubeObject {
 	if object == enums.CLUSTER_ROLE {
 		return &ClusterRole{
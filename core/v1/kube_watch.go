@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"log"
+	"sync"
+)
+
+// kubeWatchBuffer number of events buffered per watcher, events are dropped for watchers falling behind.
+const kubeWatchBuffer = 100
+
+// KubeWatchEvent kubernetes watch event of a stored object.
+type KubeWatchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// KubeWatchRequest scope of a watch. Objects carrying no company label are watched along with those of the company.
+type KubeWatchRequest struct {
+	Type          enums.RESOURCE_TYPE
+	CompanyId     string
+	AgentName     string
+	Namespace     string
+	Name          string
+	LabelSelector string
+	FieldSelector string
+}
+
+type kubeWatcher struct {
+	request KubeWatchRequest
+	labels  labels.Selector
+	fields  fields.Selector
+	events  chan KubeWatchEvent
+}
+
+func (w *kubeWatcher) matches(object enums.RESOURCE_TYPE, companyId, agent string, meta ObjectMeta) bool {
+	if w.request.Type != object || (companyId != "" && w.request.CompanyId != companyId) || w.request.AgentName != agent {
+		return false
+	}
+	if w.request.Namespace != "" && w.request.Namespace != meta.Namespace {
+		return false
+	}
+	if w.request.Name != "" && w.request.Name != meta.Name {
+		return false
+	}
+	if !w.labels.Matches(labels.Set(meta.Labels)) {
+		return false
+	}
+	return w.fields.Matches(fields.Set{"metadata.name": meta.Name, "metadata.namespace": meta.Namespace})
+}
+
+type kubeWatchHub struct {
+	mu       sync.RWMutex
+	watchers map[*kubeWatcher]struct{}
+	sequence uint64
+}
+
+var singletonKubeWatchHub *kubeWatchHub
+var onceKubeWatchHub sync.Once
+
+func getKubeWatchHub() *kubeWatchHub {
+	onceKubeWatchHub.Do(func() {
+		singletonKubeWatchHub = &kubeWatchHub{watchers: make(map[*kubeWatcher]struct{})}
+	})
+	return singletonKubeWatchHub
+}
+
+// revision returns sequence of the last published event.
+func (h *kubeWatchHub) revision() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.sequence
+}
+
+// WatchKubeObjects subscribes to changes of stored objects in scope of the request. Returned func must be called
+// to stop watching, it closes the events channel.
+func WatchKubeObjects(request KubeWatchRequest) (<-chan KubeWatchEvent, func(), error) {
+	if _, ok := GetResourceDescriptor(request.Type); !ok {
+		return nil, nil, ErrUnknownResource
+	}
+	labelSelector, err := labels.Parse(request.LabelSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid label selector: %s", err.Error())
+	}
+	fieldSelector, err := fields.ParseSelector(request.FieldSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid field selector: %s", err.Error())
+	}
+	for _, each := range fieldSelector.Requirements() {
+		if _, ok := commonSelectableFields[each.Field]; !ok || each.Field == "metadata.uid" {
+			return nil, nil, fmt.Errorf("field selector %q is not supported on watch, supported fields: metadata.name, metadata.namespace", each.Field)
+		}
+	}
+	watcher := &kubeWatcher{
+		request: request,
+		labels:  labelSelector,
+		fields:  fieldSelector,
+		events:  make(chan KubeWatchEvent, kubeWatchBuffer),
+	}
+	hub := getKubeWatchHub()
+	hub.mu.Lock()
+	hub.watchers[watcher] = struct{}{}
+	hub.mu.Unlock()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			hub.mu.Lock()
+			delete(hub.watchers, watcher)
+			close(watcher.events)
+			hub.mu.Unlock()
+		})
+	}
+	return watcher.events, stop, nil
+}
+
+// kubeWatchEventType returns watch event type of a command.
+func kubeWatchEventType(command enums.Command) string {
+	switch command {
+	case enums.ADD:
+		return "ADDED"
+	case enums.UPDATE:
+		return "MODIFIED"
+	case enums.DELETE:
+		return "DELETED"
+	}
+	return ""
+}
+
+// publishKubeEvent notifies watchers about an applied kube event.
+func publishKubeEvent(event AppliedKubeEvent) {
+	descriptor, ok := GetResourceDescriptor(event.Type)
+	eventType := kubeWatchEventType(event.Command)
+	if !ok || eventType == "" {
+		return
+	}
+	hub := getKubeWatchHub()
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.sequence++
+	var watchEvent *KubeWatchEvent
+	for watcher := range hub.watchers {
+		if !watcher.matches(event.Type, event.CompanyId, event.AgentName, event.Meta) {
+			continue
+		}
+		if watchEvent == nil {
+			obj, err := json.Marshal(event.Obj)
+			if err == nil {
+				obj, err = ToKubeObject(descriptor, obj)
+			}
+			if err != nil {
+				log.Println("[ERROR]", err)
+				return
+			}
+			watchEvent = &KubeWatchEvent{Type: eventType, Object: obj}
+		}
+		select {
+		case watcher.events <- *watchEvent:
+		default:
+			log.Println("[WARNING] kube watcher is falling behind, dropping event of", descriptor.Kind, event.Meta.Namespace+"/"+event.Meta.Name)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -31,6 +32,7 @@ type Namespace struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sNamespace `bson:"obj" json:"obj"`
 	AgentName          string       `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Namespace) deleteAll() error {
@@ -50,6 +52,7 @@ func NewNamespace() KubeObject {
 
 func (obj Namespace) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.NAMESPACE, &obj.Obj)
 	if obj.findByNamespaceAndAgentNameAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(NamespaceCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -168,7 +171,9 @@ func (obj Namespace) findAll() []K8sNamespace {
 	return k8sObjects
 }
 
-func (obj Namespace) Delete(agent string) error {
+func (obj Namespace) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.NAMESPACE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -176,7 +181,7 @@ func (obj Namespace) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(NamespaceCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 
 	if err != nil {
 		log.Println("[ERROR]", err)
@@ -194,6 +199,7 @@ func (obj Namespace) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.NAMESPACE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
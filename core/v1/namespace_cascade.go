@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"strconv"
+)
+
+// namespaceObjectsQuery matches live objects an agent of a company reported in a namespace. Like agentObjectsQuery,
+// objects carrying no company label are matched too.
+func namespaceObjectsQuery(companyId, agent, namespace string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": agent},
+			{"obj.metadata.labels.company": bson.M{"$in": []interface{}{companyId, nil, ""}}},
+			{"obj.metadata.namespace": namespace},
+			notDeleted,
+		},
+	}
+}
+
+// cascadeNamespaceDeletion tombstones every namespaced object the agent reported in a deleted namespace, as agents
+// often miss delete events of objects removed along with their namespace. Events are removed, as they are not kept
+// as tombstones. Revisions are recorded and watchers notified for every tombstoned object.
+func cascadeNamespaceDeletion(event AppliedKubeEvent) {
+	namespace := event.Meta.Name
+	if namespace == "" {
+		return
+	}
+	query := namespaceObjectsQuery(event.CompanyId, event.AgentName, namespace)
+	extra := map[string]string{"agent_name": event.AgentName, "offset": strconv.Itoa(event.Offset)}
+	var total int64
+	for _, each := range Resources {
+		if !each.Namespaced {
+			continue
+		}
+		coll := db.GetDmManager().Db.Collection(each.Collection)
+		if each.Type == enums.EVENT {
+			result, err := coll.DeleteMany(db.GetDmManager().Ctx, query)
+			if err != nil {
+				log.Println("[ERROR] Failed to remove events of namespace", namespace+":", err.Error())
+				continue
+			}
+			total += result.DeletedCount
+			continue
+		}
+		curser, err := coll.Find(db.GetDmManager().Ctx, query)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		objects := []KubeObject{}
+		for curser.Next(context.TODO()) {
+			kubeObject := GetObject(each.Type)
+			if err := bson.Unmarshal(curser.Current, kubeObject); err != nil {
+				log.Println("[ERROR]", err)
+				continue
+			}
+			objects = append(objects, kubeObject)
+		}
+		curser.Close(context.TODO())
+		for _, kubeObject := range objects {
+			if err := kubeObject.Delete(extra); err != nil {
+				continue
+			}
+			total++
+			OnKubeEventApplied(AppliedKubeEvent{
+				Type:      each.Type,
+				Command:   enums.DELETE,
+				Offset:    event.Offset,
+				AgentName: event.AgentName,
+				Object:    kubeObject,
+				AppliedAt: event.AppliedAt,
+			})
+		}
+	}
+	if total > 0 {
+		log.Println("[INFO] Removed", total, "objects of deleted namespace", namespace, "of agent", event.AgentName)
+	}
+}
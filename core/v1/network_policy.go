@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type NetworkPolicy struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sNetworkPolicy `bson:"obj" json:"obj"`
 	AgentName          string           `json:"agent_name" bson:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj NetworkPolicy) deleteAll() error {
@@ -43,6 +45,7 @@ func NewNetworkPolicy() KubeObject {
 
 func (obj NetworkPolicy) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.NETWORK_POLICY, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(NetworkPolicyCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj NetworkPolicy) findByNameAndNamespaceAndCompanyId() K8sNetworkPolicy {
 	return temp.Obj
 }
 
-func (obj NetworkPolicy) Delete(agent string) error {
+func (obj NetworkPolicy) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.NETWORK_POLICY, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -107,7 +112,7 @@ func (obj NetworkPolicy) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(NetworkPolicyCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -124,6 +129,7 @@ func (obj NetworkPolicy) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.NETWORK_POLICY, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type Node struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sNode `bson:"obj" json:"obj"`
 	AgentName          string  `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Node) deleteAll() error {
@@ -43,6 +45,7 @@ func NewNode() KubeObject {
 
 func (obj Node) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.NODE, &obj.Obj)
 	if obj.findByNameAndAgentNameAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(NodeCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -97,7 +100,9 @@ func (obj Node) findById() K8sNode {
 	return temp.Obj
 }
 
-func (obj Node) Delete(agent string) error {
+func (obj Node) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.NODE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -105,7 +110,7 @@ func (obj Node) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(NodeCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -122,6 +127,7 @@ func (obj Node) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.NODE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
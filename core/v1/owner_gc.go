@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"context"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// ownedObject stored object carrying owner references.
+type ownedObject struct {
+	Id  interface{} `bson:"_id"`
+	Obj struct {
+		ObjectMeta `bson:"metadata"`
+	} `bson:"obj"`
+	Orphaned      bool       `bson:"orphaned"`
+	OrphanedSince *time.Time `bson:"orphaned_since"`
+}
+
+// StartOwnerReferenceCollector periodically detects dependents whose owners no longer exist. Blocks forever.
+func StartOwnerReferenceCollector() {
+	if config.OwnerGCInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(config.OwnerGCInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		CollectOrphanedDependents()
+	}
+}
+
+// CollectOrphanedDependents flags stored objects of active agents whose owners no longer exist and, if policy is DELETE,
+// deletes them once orphaned longer than grace period. As in kubernetes garbage collection, a dependent is orphaned only if
+// none of its owners exist, owners of kinds or api versions that are not stored are assumed to exist. Deleting a dependent orphans its
+// own dependents, which are collected by following runs. Events are not collected.
+func CollectOrphanedDependents() {
+	now := time.Now().UTC()
+	for _, agent := range FindAgents("") {
+		if agent.Status != enums.AGENT_ACTIVE && agent.Status != "" {
+			// objects of stale agents are unverified, missing owners may not be missing
+			continue
+		}
+		owners, err := agent.liveObjectUIDs()
+		if err != nil {
+			continue
+		}
+		for _, each := range Resources {
+			if each.Type == enums.EVENT {
+				continue
+			}
+			agent.collectOrphanedDependents(each, owners, now)
+		}
+	}
+}
+
+// ownerKey returns key of a kind of owner, owners are matched by api version, kind and uid.
+func ownerKey(apiVersion, kind string) string {
+	return apiVersion + "/" + kind
+}
+
+// liveObjectUIDs returns uids of stored objects of the agent by api version and kind. Objects are not scoped by company,
+// owners do not necessarily carry the company label of their dependents.
+func (a Agent) liveObjectUIDs() (map[string]map[UID]bool, error) {
+	uids := make(map[string]map[UID]bool)
+	query := andFilter([]bson.M{{"agent_name": a.Name}, notDeleted})
+	opts := options.Find().SetProjection(bson.M{"obj.metadata.uid": 1})
+	for _, each := range Resources {
+		if each.Type == enums.EVENT {
+			continue
+		}
+		key := ownerKey(each.APIVersion, each.Kind)
+		uids[key] = make(map[UID]bool)
+		curser, err := db.GetDmManager().Db.Collection(each.Collection).Find(db.GetDmManager().Ctx, query, opts)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			return nil, err
+		}
+		for curser.Next(context.TODO()) {
+			var object ownedObject
+			if err := curser.Decode(&object); err != nil {
+				log.Println("[ERROR]", err)
+				continue
+			}
+			uids[key][object.Obj.UID] = true
+		}
+		curser.Close(context.TODO())
+	}
+	return uids, nil
+}
+
+// orphaned returns true if none of the owners of the object exist.
+func (o ownedObject) orphaned(owners map[string]map[UID]bool) bool {
+	for _, each := range o.Obj.OwnerReferences {
+		uids, ok := owners[ownerKey(each.APIVersion, each.Kind)]
+		if !ok || uids[each.UID] {
+			return false
+		}
+	}
+	return len(o.Obj.OwnerReferences) > 0
+}
+
+func (a Agent) collectOrphanedDependents(descriptor ResourceDescriptor, owners map[string]map[UID]bool, now time.Time) {
+	coll := db.GetDmManager().Db.Collection(descriptor.Collection)
+	query := andFilter([]bson.M{
+		{"agent_name": a.Name},
+		notDeleted,
+		{"obj.metadata.ownerReferences.0": bson.M{"$exists": true}},
+	})
+	opts := options.Find().SetProjection(bson.M{"obj.metadata": 1, "orphaned": 1, "orphaned_since": 1})
+	curser, err := coll.Find(db.GetDmManager().Ctx, query, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return
+	}
+	var flag, unflag, collect []interface{}
+	for curser.Next(context.TODO()) {
+		var object ownedObject
+		if err := curser.Decode(&object); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		switch {
+		case !object.orphaned(owners):
+			if object.Orphaned {
+				unflag = append(unflag, object.Id)
+			}
+		case !object.Orphaned || object.OrphanedSince == nil:
+			flag = append(flag, object.Id)
+		case enums.ORPHAN_POLICY(config.OwnerGCPolicy) == enums.ORPHAN_DELETE && now.Sub(*object.OrphanedSince) >= config.OwnerGCGracePeriod:
+			collect = append(collect, object.Id)
+		}
+	}
+	curser.Close(context.TODO())
+	if len(flag) > 0 {
+		_, err := coll.UpdateMany(db.GetDmManager().Ctx, bson.M{"_id": bson.M{"$in": flag}}, bson.M{"$set": bson.M{"orphaned": true, "orphaned_since": now}})
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+	}
+	if len(unflag) > 0 {
+		_, err := coll.UpdateMany(db.GetDmManager().Ctx, bson.M{"_id": bson.M{"$in": unflag}}, bson.M{"$unset": bson.M{"orphaned": "", "orphaned_since": ""}})
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+	}
+	for _, id := range collect {
+		raw, err := coll.FindOne(db.GetDmManager().Ctx, bson.M{"_id": id}).DecodeBytes()
+		if err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		kubeObject := GetObject(descriptor.Type)
+		if err := bson.Unmarshal(raw, kubeObject); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		if err := kubeObject.Delete(map[string]string{"agent_name": a.Name}); err != nil {
+			continue
+		}
+		log.Println("[INFO] Deleted orphaned", descriptor.Kind, "of agent", a.Name, "of company", a.CompanyId)
+		OnKubeEventApplied(AppliedKubeEvent{
+			Type:      descriptor.Type,
+			Command:   enums.DELETE,
+			AgentName: a.Name,
+			Object:    kubeObject,
+			AppliedAt: time.Now().UTC(),
+		})
+	}
+}
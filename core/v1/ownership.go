@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"sort"
+)
+
+// ErrNotWorkload returned when ownership tree is requested for a kind that is not a workload.
+var ErrNotWorkload = errors.New("resource type is not a workload, supported: deployment, replicaset, statefulset, daemonset, pod")
+
+// workloadTypes resource types forming ownership trees of workloads.
+var workloadTypes = []enums.RESOURCE_TYPE{enums.DEPLOYMENT, enums.REPLICASET, enums.STATEFULSET, enums.DAEMONSET, enums.POD}
+
+// ContainerSummary status of a container of a pod.
+type ContainerSummary struct {
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restart_count"`
+	// State one of waiting, running and terminated, along with reason if any.
+	State  string `json:"state,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// WorkloadStatus summarized status of a workload or pod. Pod counts cover every pod below the node.
+type WorkloadStatus struct {
+	DesiredReplicas   *int32             `json:"desired_replicas,omitempty"`
+	Replicas          int32              `json:"replicas"`
+	ReadyReplicas     int32              `json:"ready_replicas"`
+	AvailableReplicas int32              `json:"available_replicas"`
+	Phase             string             `json:"phase,omitempty"`
+	Containers        []ContainerSummary `json:"containers,omitempty"`
+	PodPhases         map[string]int     `json:"pod_phases"`
+	Restarts          int32              `json:"restarts"`
+}
+
+// OwnershipNode object of an ownership tree along with objects it owns.
+type OwnershipNode struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	UID       UID             `json:"uid"`
+	Status    WorkloadStatus  `json:"status"`
+	Children  []OwnershipNode `json:"children,omitempty"`
+	meta      ObjectMeta
+}
+
+func containerSummaries(statuses []ContainerStatus) []ContainerSummary {
+	summaries := []ContainerSummary{}
+	for _, each := range statuses {
+		summary := ContainerSummary{Name: each.Name, Image: each.Image, Ready: each.Ready, RestartCount: each.RestartCount}
+		switch {
+		case each.State.Waiting != nil:
+			summary.State, summary.Reason = "waiting", each.State.Waiting.Reason
+		case each.State.Running != nil:
+			summary.State = "running"
+		case each.State.Terminated != nil:
+			summary.State, summary.Reason = "terminated", each.State.Terminated.Reason
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// newOwnershipNode returns node of a decoded stored workload or pod.
+func newOwnershipNode(kubeObject KubeObject) (OwnershipNode, bool) {
+	node := OwnershipNode{Status: WorkloadStatus{PodPhases: map[string]int{}}}
+	switch object := kubeObject.(type) {
+	case *Deployment:
+		node.Kind, node.meta = "Deployment", object.Obj.ObjectMeta
+		node.Status.DesiredReplicas = object.Obj.Spec.Replicas
+		node.Status.Replicas = object.Obj.Status.Replicas
+		node.Status.ReadyReplicas = object.Obj.Status.ReadyReplicas
+		node.Status.AvailableReplicas = object.Obj.Status.AvailableReplicas
+	case *ReplicaSet:
+		node.Kind, node.meta = "ReplicaSet", object.Obj.ObjectMeta
+		node.Status.DesiredReplicas = object.Obj.Spec.Replicas
+		node.Status.Replicas = object.Obj.Status.Replicas
+		node.Status.ReadyReplicas = object.Obj.Status.ReadyReplicas
+		node.Status.AvailableReplicas = object.Obj.Status.AvailableReplicas
+	case *StatefulSet:
+		node.Kind, node.meta = "StatefulSet", object.Obj.ObjectMeta
+		node.Status.DesiredReplicas = object.Obj.Spec.Replicas
+		node.Status.Replicas = object.Obj.Status.Replicas
+		node.Status.ReadyReplicas = object.Obj.Status.ReadyReplicas
+		// stored statefulset status carries no available replicas
+		node.Status.AvailableReplicas = object.Obj.Status.ReadyReplicas
+	case *DaemonSet:
+		desired := object.Obj.Status.DesiredNumberScheduled
+		node.Kind, node.meta = "DaemonSet", object.Obj.ObjectMeta
+		node.Status.DesiredReplicas = &desired
+		node.Status.Replicas = object.Obj.Status.CurrentNumberScheduled
+		node.Status.ReadyReplicas = object.Obj.Status.NumberReady
+		node.Status.AvailableReplicas = object.Obj.Status.NumberAvailable
+	case *Pod:
+		node.Kind, node.meta = "Pod", object.Obj.ObjectMeta
+		node.Status.Phase = string(object.Obj.Status.Phase)
+		node.Status.Containers = containerSummaries(object.Obj.Status.ContainerStatuses)
+		node.Status.PodPhases[node.Status.Phase]++
+		for _, each := range node.Status.Containers {
+			node.Status.Restarts += each.RestartCount
+		}
+	default:
+		return OwnershipNode{}, false
+	}
+	node.Name, node.Namespace, node.UID = node.meta.Name, node.meta.Namespace, node.meta.UID
+	return node, true
+}
+
+// findWorkloadNodes returns nodes of every stored workload and pod of an agent in a namespace.
+func findWorkloadNodes(companyId, agent, namespace string) ([]OwnershipNode, error) {
+	nodes := []OwnershipNode{}
+	for _, object := range workloadTypes {
+		kubeObjects, err := findLiveObjects(object, companyId, agent, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, kubeObject := range kubeObjects {
+			if node, ok := newOwnershipNode(kubeObject); ok {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// buildOwnershipTree attaches owned nodes below node and adds up pod phases and restarts of its descendants.
+func buildOwnershipTree(node *OwnershipNode, owned map[UID][]OwnershipNode, visited map[UID]bool) {
+	visited[node.UID] = true
+	for _, child := range owned[node.UID] {
+		if visited[child.UID] {
+			continue
+		}
+		phases := make(map[string]int, len(child.Status.PodPhases))
+		for phase, count := range child.Status.PodPhases {
+			phases[phase] = count
+		}
+		child.Status.PodPhases = phases
+		buildOwnershipTree(&child, owned, visited)
+		for phase, count := range child.Status.PodPhases {
+			node.Status.PodPhases[phase] += count
+		}
+		node.Status.Restarts += child.Status.Restarts
+		node.Children = append(node.Children, child)
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		if node.Children[i].Kind != node.Children[j].Kind {
+			return node.Children[i].Kind < node.Children[j].Kind
+		}
+		return node.Children[i].Name < node.Children[j].Name
+	})
+}
+
+// FindOwnershipTree returns ownership tree of a stored workload, e.g. Deployment to its ReplicaSets to their Pods.
+func FindOwnershipTree(object enums.RESOURCE_TYPE, companyId, agent, namespace, name string) (OwnershipNode, error) {
+	isWorkload := false
+	for _, each := range workloadTypes {
+		isWorkload = isWorkload || each == object
+	}
+	if !isWorkload {
+		return OwnershipNode{}, ErrNotWorkload
+	}
+	descriptor, _ := GetResourceDescriptor(object)
+	nodes, err := findWorkloadNodes(companyId, agent, namespace)
+	if err != nil {
+		return OwnershipNode{}, err
+	}
+	var root *OwnershipNode
+	owned := make(map[UID][]OwnershipNode)
+	for i, node := range nodes {
+		if node.Kind == descriptor.Kind && node.Name == name {
+			root = &nodes[i]
+		}
+		for _, owner := range node.meta.OwnerReferences {
+			owned[owner.UID] = append(owned[owner.UID], node)
+		}
+	}
+	if root == nil {
+		return OwnershipNode{}, ErrResourceNotFound
+	}
+	buildOwnershipTree(root, owned, map[UID]bool{})
+	return *root, nil
+}
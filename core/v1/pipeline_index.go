@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const PipelineIndexCollection = "pipelineIndexCollection"
+
+// ErrPipelineIdentifierRequired returned when a pipeline query names no repository, application or process id.
+var ErrPipelineIdentifierRequired = errors.New("repository, application or process id is required")
+
+// PipelineObject object stamped by a pipeline run. An object redeployed by another run is indexed once per run, the
+// entry of the latest run being current.
+type PipelineObject struct {
+	Kind          enums.RESOURCE_TYPE `json:"kind" bson:"kind"`
+	CompanyId     string              `json:"company" bson:"company"`
+	AgentName     string              `json:"agent_name" bson:"agent_name"`
+	Namespace     string              `json:"namespace" bson:"namespace"`
+	Name          string              `json:"name" bson:"name"`
+	UID           string              `json:"uid" bson:"uid"`
+	RepositoryId  string              `json:"repository,omitempty" bson:"repository"`
+	ApplicationId string              `json:"application,omitempty" bson:"application"`
+	ProcessId     string              `json:"process_id,omitempty" bson:"process_id"`
+	// Current set if the object still carries identifiers of the run.
+	Current   bool       `json:"current" bson:"current"`
+	Deleted   bool       `json:"deleted,omitempty" bson:"deleted"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at"`
+	IndexedAt time.Time  `json:"indexed_at" bson:"indexed_at"`
+	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// PipelineQuery scope and page of a pipeline objects query. At least one of repository, application and process id
+// is required.
+type PipelineQuery struct {
+	CompanyId     string
+	RepositoryId  string
+	ApplicationId string
+	ProcessId     string
+	AgentName     string
+	Type          enums.RESOURCE_TYPE
+	// IncludeDeleted includes deleted objects.
+	IncludeDeleted bool
+	Page           int64
+	Limit          int64
+}
+
+func (q PipelineQuery) filter() (bson.M, error) {
+	if q.RepositoryId == "" && q.ApplicationId == "" && q.ProcessId == "" {
+		return nil, ErrPipelineIdentifierRequired
+	}
+	conditions := []bson.M{}
+	if q.CompanyId != "" {
+		conditions = append(conditions, bson.M{"company": q.CompanyId})
+	}
+	if q.RepositoryId != "" {
+		conditions = append(conditions, bson.M{"repository": q.RepositoryId})
+	}
+	if q.ApplicationId != "" {
+		conditions = append(conditions, bson.M{"application": q.ApplicationId})
+	}
+	if q.ProcessId != "" {
+		conditions = append(conditions, bson.M{"process_id": q.ProcessId})
+	}
+	if q.AgentName != "" {
+		conditions = append(conditions, bson.M{"agent_name": q.AgentName})
+	}
+	if q.Type != "" {
+		if _, ok := GetResourceDescriptor(q.Type); !ok {
+			return nil, ErrUnknownResource
+		}
+		conditions = append(conditions, bson.M{"kind": q.Type})
+	}
+	if !q.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+	return andFilter(conditions), nil
+}
+
+// pipelineObjectQuery returns filter of index entries of an object.
+func pipelineObjectQuery(object enums.RESOURCE_TYPE, agent, namespace, name string) []bson.M {
+	return []bson.M{
+		{"kind": object},
+		{"agent_name": agent},
+		{"namespace": namespace},
+		{"name": name},
+	}
+}
+
+// newPipelineObject returns index entry of an object, ok is false if the object carries no pipeline identifier.
+func newPipelineObject(object enums.RESOURCE_TYPE, companyId, agent string, meta ObjectMeta) (PipelineObject, bool) {
+	entry := PipelineObject{
+		Kind:          object,
+		CompanyId:     companyId,
+		AgentName:     agent,
+		Namespace:     meta.Namespace,
+		Name:          meta.Name,
+		UID:           string(meta.UID),
+		RepositoryId:  pipelineIdentifier(meta, config.PipelineRepositoryKey),
+		ApplicationId: pipelineIdentifier(meta, config.PipelineApplicationKey),
+		ProcessId:     pipelineIdentifier(meta, config.PipelineProcessIdKey),
+		Current:       true,
+	}
+	return entry, entry.RepositoryId != "" || entry.ApplicationId != "" || entry.ProcessId != ""
+}
+
+// save upserts the entry as current entry of the object, entries of previous runs are no longer current.
+func (p PipelineObject) save(at time.Time) error {
+	coll := db.GetDmManager().Db.Collection(PipelineIndexCollection)
+	query := pipelineObjectQuery(p.Kind, p.AgentName, p.Namespace, p.Name)
+	_, err := coll.UpdateMany(db.GetDmManager().Ctx, andFilter(append(query, bson.M{"process_id": bson.M{"$ne": p.ProcessId}})), bson.M{"$set": bson.M{"current": false}})
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"company":     p.CompanyId,
+			"uid":         p.UID,
+			"repository":  p.RepositoryId,
+			"application": p.ApplicationId,
+			"current":     true,
+			"deleted":     false,
+			"deleted_at":  nil,
+			"updated_at":  at,
+		},
+		"$setOnInsert": bson.M{"indexed_at": at},
+	}
+	_, err = coll.UpdateOne(db.GetDmManager().Ctx, andFilter(append(query, bson.M{"process_id": p.ProcessId})), update, options.Update().SetUpsert(true))
+	return err
+}
+
+// indexPipelineObject keeps pipeline index of the applied object. Deleted objects stay indexed, flagged as deleted.
+// An object whose pipeline identifiers are removed is no longer current for any run.
+func indexPipelineObject(event AppliedKubeEvent) {
+	if event.Type == enums.EVENT {
+		return
+	}
+	coll := db.GetDmManager().Db.Collection(PipelineIndexCollection)
+	query := andFilter(pipelineObjectQuery(event.Type, event.AgentName, event.Meta.Namespace, event.Meta.Name))
+	var err error
+	if event.Command == enums.DELETE {
+		_, err = coll.UpdateMany(db.GetDmManager().Ctx, query, bson.M{"$set": bson.M{"deleted": true, "deleted_at": event.AppliedAt, "current": false}})
+	} else if entry, ok := newPipelineObject(event.Type, event.CompanyId, event.AgentName, event.Meta); ok {
+		err = entry.save(event.AppliedAt)
+	} else if event.Command == enums.UPDATE {
+		_, err = coll.UpdateMany(db.GetDmManager().Ctx, query, bson.M{"$set": bson.M{"current": false}})
+	}
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+}
+
+// FindPipelineObjects returns a page of objects stamped by a pipeline run, application or repository across agents and
+// kinds, and total count of objects matching the query.
+func FindPipelineObjects(q PipelineQuery) ([]PipelineObject, int64, error) {
+	filter, err := q.filter()
+	if err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	} else if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	coll := db.GetDmManager().Db.Collection(PipelineIndexCollection)
+	total, err := coll.CountDocuments(db.GetDmManager().Ctx, filter)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "agent_name", Value: 1}, {Key: "kind", Value: 1}, {Key: "namespace", Value: 1}, {Key: "name", Value: 1}, {Key: "indexed_at", Value: 1}}).
+		SetSkip(q.Page * q.Limit).
+		SetLimit(q.Limit)
+	curser, err := coll.Find(db.GetDmManager().Ctx, filter, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	defer curser.Close(context.TODO())
+	objects := []PipelineObject{}
+	for curser.Next(context.TODO()) {
+		var object PipelineObject
+		if err := curser.Decode(&object); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		objects = append(objects, object)
+	}
+	return objects, total, nil
+}
+
+// EnsurePipelineIndexes creates indexes of the pipeline index and indexes stored objects stamped before pipeline
+// indexing existed.
+func EnsurePipelineIndexes() {
+	coll := db.GetDmManager().Db.Collection(PipelineIndexCollection)
+	_, err := coll.Indexes().CreateMany(db.GetDmManager().Ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "agent_name", Value: 1}, {Key: "namespace", Value: 1}, {Key: "name", Value: 1}, {Key: "process_id", Value: 1}}},
+		{Keys: bson.D{{Key: "company", Value: 1}, {Key: "process_id", Value: 1}}},
+		{Keys: bson.D{{Key: "company", Value: 1}, {Key: "application", Value: 1}}},
+		{Keys: bson.D{{Key: "company", Value: 1}, {Key: "repository", Value: 1}}},
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create pipeline indexes:", err.Error())
+	}
+	count, err := coll.EstimatedDocumentCount(db.GetDmManager().Ctx)
+	if err != nil || count > 0 {
+		return
+	}
+	stamped := []bson.M{}
+	for _, key := range []string{config.PipelineRepositoryKey, config.PipelineApplicationKey, config.PipelineProcessIdKey} {
+		if key == "" {
+			continue
+		}
+		stamped = append(stamped, bson.M{"obj.metadata.labels." + key: bson.M{"$exists": true}}, bson.M{"obj.metadata.annotations." + key: bson.M{"$exists": true}})
+	}
+	if len(stamped) == 0 {
+		return
+	}
+	filter := andFilter([]bson.M{notDeleted, {"$or": stamped}})
+	now := time.Now().UTC()
+	for _, each := range Resources {
+		if each.Type == enums.EVENT {
+			continue
+		}
+		curser, err := db.GetDmManager().Db.Collection(each.Collection).Find(db.GetDmManager().Ctx, filter)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		for curser.Next(context.TODO()) {
+			var stored struct {
+				AgentName string `bson:"agent_name"`
+				Obj       struct {
+					ObjectMeta `bson:"metadata"`
+				} `bson:"obj"`
+			}
+			if err := curser.Decode(&stored); err != nil {
+				log.Println("[ERROR]", err)
+				continue
+			}
+			if entry, ok := newPipelineObject(each.Type, stored.Obj.Labels["company"], stored.AgentName, stored.Obj.ObjectMeta); ok {
+				if err := entry.save(now); err != nil {
+					log.Println("[ERROR]", err)
+				}
+			}
+		}
+		curser.Close(context.TODO())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type Pod struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sPod `bson:"obj" json:"obj"`
 	AgentName          string `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Pod) deleteAll() error {
@@ -43,6 +45,7 @@ func NewPod() KubeObject {
 
 func (obj Pod) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.POD, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(PodCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -122,7 +125,9 @@ func (obj Pod) findByLabel() []K8sPod {
 	return k8sObjects
 }
 
-func (obj Pod) Delete(agent string) error {
+func (obj Pod) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.POD, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -132,7 +137,7 @@ func (obj Pod) Delete(agent string) error {
 	}
 	log.Println("deleting pod:", obj.Obj.Name+"!")
 	coll := db.GetDmManager().Db.Collection(PodCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 
 	if err != nil {
 		log.Println("[ERROR]", err)
@@ -151,6 +156,7 @@ func (obj Pod) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.POD, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": oldObject.Obj.Name},
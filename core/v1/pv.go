@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type PersistentVolume struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sPersistentVolume `bson:"obj" json:"obj"`
 	AgentName          string              `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj PersistentVolume) deleteAll() error {
@@ -44,6 +46,7 @@ func NewPersistentVolume() KubeObject {
 
 func (obj PersistentVolume) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.PERSISTENT_VOLUME, &obj.Obj)
 	if obj.findByNameAndAgentNameAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(PVCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj PersistentVolume) findByNameAndAgentNameAndCompanyId() K8sPersistentVo
 	return temp.Obj
 }
 
-func (obj PersistentVolume) Delete(agent string) error {
+func (obj PersistentVolume) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.PERSISTENT_VOLUME, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -106,7 +111,7 @@ func (obj PersistentVolume) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(PVCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -123,6 +128,7 @@ func (obj PersistentVolume) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.PERSISTENT_VOLUME, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type PersistentVolumeClaim struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sPersistentVolumeClaim `bson:"obj" json:"obj"`
 	AgentName          string                   `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj PersistentVolumeClaim) deleteAll() error {
@@ -44,6 +46,7 @@ func NewPersistentVolumeClaim() KubeObject {
 
 func (obj PersistentVolumeClaim) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.PERSISTENT_VOLUME_CLAIM, &obj.Obj)
 	if obj.findByNameAndNamespace().Name == "" {
 		coll := db.GetDmManager().Db.Collection(PVCCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj PersistentVolumeClaim) findById() K8sPersistentVolumeClaim {
 	return temp.Obj
 }
 
-func (obj PersistentVolumeClaim) Delete(agent string) error {
+func (obj PersistentVolumeClaim) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.PERSISTENT_VOLUME_CLAIM, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -107,7 +112,7 @@ func (obj PersistentVolumeClaim) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(PVCCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -124,6 +129,7 @@ func (obj PersistentVolumeClaim) Update(oldObj interface{}, agent string) error
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.PERSISTENT_VOLUME_CLAIM, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
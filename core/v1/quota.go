@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+// QuotaRetryAfter refers to suggested retry interval of requests rejected by storage quota.
+const QuotaRetryAfter = time.Minute
+
+// ErrQuotaExceeded returned when storing an object would exceed company quota.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// KindQuotaUsage stored object count of a kind against its quota.
+type KindQuotaUsage struct {
+	Kind  enums.RESOURCE_TYPE `json:"kind"`
+	Count int64               `json:"count"`
+	Limit int64               `json:"limit"`
+}
+
+// Usage rate limit and quota usage.
+type Usage struct {
+	RateLimits []RateLimitUsage `json:"rate_limits"`
+	Quotas     []KindQuotaUsage `json:"quotas,omitempty"`
+}
+
+func getObjectQuota(object enums.RESOURCE_TYPE) int64 {
+	if limit, ok := config.MaxObjectsPerKindOverrides[string(object)]; ok {
+		return limit
+	}
+	return config.MaxObjectsPerKind
+}
+
+// CheckObjectQuota returns ErrQuotaExceeded if storing a new object would exceed company quota of the kind.
+// Objects that are already stored are always allowed.
+func CheckObjectQuota(object enums.RESOURCE_TYPE, companyId, agent string, meta ObjectMeta) error {
+	limit := getObjectQuota(object)
+	descriptor, ok := GetResourceDescriptor(object)
+	if limit <= 0 || companyId == "" || !ok {
+		return nil
+	}
+	coll := db.GetDmManager().Db.Collection(descriptor.Collection)
+	query := bson.M{
+		"obj.metadata.name":           meta.Name,
+		"obj.metadata.labels.company": companyId,
+		"agent_name":                  agent,
+		"deleted":                     bson.M{"$ne": true},
+	}
+	if descriptor.Namespaced {
+		query["obj.metadata.namespace"] = meta.Namespace
+	}
+	existing, err := coll.CountDocuments(db.GetDmManager().Ctx, query, options.Count().SetLimit(1))
+	if err == nil && existing > 0 {
+		return nil
+	}
+	count, err := coll.CountDocuments(db.GetDmManager().Ctx, bson.M{"obj.metadata.labels.company": companyId, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil
+	}
+	if count >= limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// GetQuotaUsage returns stored object count of every kind of a company.
+func GetQuotaUsage(companyId string) []KindQuotaUsage {
+	usages := []KindQuotaUsage{}
+	for _, each := range Resources {
+		coll := db.GetDmManager().Db.Collection(each.Collection)
+		count, err := coll.CountDocuments(db.GetDmManager().Ctx, bson.M{"obj.metadata.labels.company": companyId, "deleted": bson.M{"$ne": true}})
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+		usages = append(usages, KindQuotaUsage{
+			Kind:  each.Type,
+			Count: count,
+			Limit: getObjectQuota(each.Type),
+		})
+	}
+	return usages
+}
+
+// GetUsage returns rate limit usage and, if companyId is given, quota usage of the company.
+func GetUsage(companyId string) Usage {
+	usage := Usage{
+		RateLimits: GetRateLimitUsage(companyId),
+	}
+	if companyId != "" {
+		usage.Quotas = GetQuotaUsage(companyId)
+	}
+	return usage
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	agentBucketPrefix   = "agent:"
+	companyBucketPrefix = "company:"
+)
+
+type tokenBucket struct {
+	rate      float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	allowed   int64
+	throttled int64
+}
+
+// refill adds tokens earned since last refill.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// wait returns how long until a token is available.
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// RateLimitUsage current state of a rate limit bucket.
+type RateLimitUsage struct {
+	Key       string  `json:"key"`
+	Rate      float64 `json:"rate"`
+	Burst     float64 `json:"burst"`
+	Available float64 `json:"available"`
+	Allowed   int64   `json:"allowed"`
+	Throttled int64   `json:"throttled"`
+}
+
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+var singletonRateLimiter *rateLimiter
+var onceRateLimiter sync.Once
+
+func getRateLimiter() *rateLimiter {
+	onceRateLimiter.Do(func() {
+		singletonRateLimiter = &rateLimiter{buckets: make(map[string]*tokenBucket)}
+	})
+	return singletonRateLimiter
+}
+
+func (l *rateLimiter) bucket(key string, rate float64, burst int, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+	b.rate = rate
+	b.burst = float64(burst)
+	b.refill(now)
+	return b
+}
+
+// AllowKubeEvent consumes a token from agent and company buckets. If any of them is empty,
+// nothing is consumed and the duration to wait before retrying is returned.
+func AllowKubeEvent(companyId, agent string) (bool, time.Duration) {
+	limiter := getRateLimiter()
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := time.Now()
+	var buckets []*tokenBucket
+	if agent != "" {
+		if b := limiter.bucket(agentBucketPrefix+companyId+"/"+agent, config.AgentRateLimit, config.AgentRateLimitBurst, now); b != nil {
+			buckets = append(buckets, b)
+		}
+	}
+	if companyId != "" {
+		if b := limiter.bucket(companyBucketPrefix+companyId, config.CompanyRateLimit, config.CompanyRateLimitBurst, now); b != nil {
+			buckets = append(buckets, b)
+		}
+	}
+	var retryAfter time.Duration
+	for _, b := range buckets {
+		if wait := b.wait(); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		for _, b := range buckets {
+			b.throttled++
+		}
+		return false, retryAfter
+	}
+	for _, b := range buckets {
+		b.tokens--
+		b.allowed++
+	}
+	return true, 0
+}
+
+// GetRateLimitUsage returns rate limit buckets of a company and its agents, all buckets if companyId is empty.
+func GetRateLimitUsage(companyId string) []RateLimitUsage {
+	limiter := getRateLimiter()
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := time.Now()
+	usages := []RateLimitUsage{}
+	for key, b := range limiter.buckets {
+		if companyId != "" && key != companyBucketPrefix+companyId && !strings.HasPrefix(key, agentBucketPrefix+companyId+"/") {
+			continue
+		}
+		b.refill(now)
+		usages = append(usages, RateLimitUsage{
+			Key:       key,
+			Rate:      b.rate,
+			Burst:     b.burst,
+			Available: math.Floor(b.tokens),
+			Allowed:   b.allowed,
+			Throttled: b.throttled,
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Key < usages[j].Key
+	})
+	return usages
+}
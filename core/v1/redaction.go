@@ -0,0 +1,385 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces the value of every redacted field.
+const RedactedValue = "**REDACTED**"
+
+// sensitiveKeyPattern matches keys and env var names that usually carry credentials.
+const sensitiveKeyPattern = `(?i)(password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential)`
+
+// RedactionRule describes fields of a kind that must be redacted before persistence.
+type RedactionRule struct {
+	// Kind resource type the rule applies to (e.g. pod, configMap). "*" applies to all kinds.
+	Kind string `json:"kind" bson:"kind"`
+	// Path dot separated field path, e.g. spec.containers[*].env[*].value or data.*
+	Path string `json:"path" bson:"path"`
+	// KeyPattern optional regular expression, matched against the field's map key or the name of its list entry.
+	KeyPattern string `json:"key_pattern,omitempty" bson:"key_pattern"`
+	// ValuePattern optional regular expression, matched against the field's value.
+	ValuePattern string `json:"value_pattern,omitempty" bson:"value_pattern"`
+}
+
+// RedactedField describes a field that has been (or would be) redacted.
+type RedactedField struct {
+	Path string        `json:"path"`
+	Rule RedactionRule `json:"rule"`
+}
+
+var defaultRedactionRules = []RedactionRule{
+	{Kind: "*", Path: "metadata.annotations.*", KeyPattern: `^kubectl\.kubernetes\.io/last-applied-configuration$`},
+	{Kind: string(enums.SECRET), Path: "data.*"},
+	{Kind: string(enums.SECRET), Path: "stringData.*"},
+	{Kind: string(enums.CONFIG_MAP), Path: "data.*", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.POD), Path: "spec.containers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.POD), Path: "spec.initContainers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.DEPLOYMENT), Path: "spec.template.spec.containers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.DEPLOYMENT), Path: "spec.template.spec.initContainers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.REPLICASET), Path: "spec.template.spec.containers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.REPLICASET), Path: "spec.template.spec.initContainers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.STATEFULSET), Path: "spec.template.spec.containers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.STATEFULSET), Path: "spec.template.spec.initContainers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.DAEMONSET), Path: "spec.template.spec.containers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+	{Kind: string(enums.DAEMONSET), Path: "spec.template.spec.initContainers[*].env[*].value", KeyPattern: sensitiveKeyPattern},
+}
+
+type redactionPathSegment struct {
+	name string
+	each bool
+}
+
+type redactionCursor struct {
+	path  string
+	key   string
+	entry string
+}
+
+type compiledRedactionRule struct {
+	rule     RedactionRule
+	segments []redactionPathSegment
+	key      *regexp.Regexp
+	value    *regexp.Regexp
+}
+
+type redactionEngine struct {
+	rules []compiledRedactionRule
+}
+
+var singletonRedactionEngine *redactionEngine
+var onceRedactionEngine sync.Once
+
+func getRedactionEngine() *redactionEngine {
+	onceRedactionEngine.Do(func() {
+		rules := defaultRedactionRules
+		if config.RedactionPolicyFile != "" {
+			loaded, err := loadRedactionRules(config.RedactionPolicyFile)
+			if err != nil {
+				log.Println("[ERROR] Failed to load redaction policy, falling back to default:", err.Error())
+			} else {
+				rules = loaded
+			}
+		}
+		singletonRedactionEngine = newRedactionEngine(rules)
+	})
+	return singletonRedactionEngine
+}
+
+func loadRedactionRules(path string) ([]RedactionRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []RedactionRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func newRedactionEngine(rules []RedactionRule) *redactionEngine {
+	engine := &redactionEngine{}
+	for _, each := range rules {
+		compiled := compiledRedactionRule{rule: each}
+		for _, part := range strings.Split(each.Path, ".") {
+			if part == "" {
+				continue
+			}
+			segment := redactionPathSegment{name: part}
+			if strings.HasSuffix(part, "[*]") {
+				segment.name = strings.TrimSuffix(part, "[*]")
+				segment.each = true
+			}
+			compiled.segments = append(compiled.segments, segment)
+		}
+		if len(compiled.segments) == 0 {
+			log.Println("[WARNING] Skipping redaction rule with empty path for kind:", each.Kind)
+			continue
+		}
+		var err error
+		if each.KeyPattern != "" {
+			if compiled.key, err = regexp.Compile(each.KeyPattern); err != nil {
+				log.Println("[WARNING] Skipping redaction rule", each.Path, ":", err.Error())
+				continue
+			}
+		}
+		if each.ValuePattern != "" {
+			if compiled.value, err = regexp.Compile(each.ValuePattern); err != nil {
+				log.Println("[WARNING] Skipping redaction rule", each.Path, ":", err.Error())
+				continue
+			}
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine
+}
+
+// GetRedactionRules returns active redaction rules.
+func GetRedactionRules() []RedactionRule {
+	rules := []RedactionRule{}
+	for _, each := range getRedactionEngine().rules {
+		rules = append(rules, each.rule)
+	}
+	return rules
+}
+
+// Redact redacts fields of obj in place according to the active policy. obj must be a pointer.
+func Redact(kind enums.RESOURCE_TYPE, obj interface{}) []RedactedField {
+	return getRedactionEngine().apply(kind, obj, false)
+}
+
+// RedactionDryRun reports fields of obj that would be redacted, without modifying it.
+func RedactionDryRun(kind enums.RESOURCE_TYPE, obj interface{}) []RedactedField {
+	return getRedactionEngine().apply(kind, obj, true)
+}
+
+func (e *redactionEngine) apply(kind enums.RESOURCE_TYPE, obj interface{}, dryRun bool) []RedactedField {
+	report := []RedactedField{}
+	for _, each := range e.rules {
+		if each.rule.Kind != "*" && !strings.EqualFold(each.rule.Kind, string(kind)) {
+			continue
+		}
+		each.walk(reflect.ValueOf(obj), each.segments, redactionCursor{}, dryRun, &report)
+	}
+	return report
+}
+
+func (r compiledRedactionRule) walk(v reflect.Value, segments []redactionPathSegment, cursor redactionCursor, dryRun bool, report *[]RedactedField) {
+	if !v.IsValid() {
+		return
+	}
+	if len(segments) == 0 {
+		r.redact(v, cursor, dryRun, report)
+		return
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		inner := v.Elem()
+		if inner.CanSet() || inner.Kind() == reflect.Map || inner.Kind() == reflect.Slice || inner.Kind() == reflect.Ptr {
+			r.walk(inner, segments, cursor, dryRun, report)
+			return
+		}
+		copied := reflect.New(inner.Type()).Elem()
+		copied.Set(inner)
+		r.descend(copied, func(changed reflect.Value) {
+			if v.CanSet() {
+				v.Set(changed)
+			}
+		}, false, segments, cursor, dryRun, report)
+		return
+	}
+	segment := segments[0]
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			if segment.name != "*" && key.String() != segment.name {
+				continue
+			}
+			copied := reflect.New(v.Type().Elem()).Elem()
+			copied.Set(v.MapIndex(key))
+			mapKey := key
+			r.descend(copied, func(changed reflect.Value) {
+				v.SetMapIndex(mapKey, changed)
+			}, segment.each, segments[1:], cursor.child(key.String()), dryRun, report)
+		}
+	case reflect.Struct:
+		for _, field := range jsonFieldsOf(v) {
+			if segment.name != "*" && field.name != segment.name {
+				continue
+			}
+			r.descend(field.value, nil, segment.each, segments[1:], cursor.child(field.name), dryRun, report)
+		}
+	}
+}
+
+func (r compiledRedactionRule) descend(child reflect.Value, commit func(reflect.Value), each bool, segments []redactionPathSegment, cursor redactionCursor, dryRun bool, report *[]RedactedField) {
+	before := len(*report)
+	if each {
+		list := child
+		for list.Kind() == reflect.Ptr || list.Kind() == reflect.Interface {
+			if list.IsNil() {
+				return
+			}
+			list = list.Elem()
+		}
+		if list.Kind() != reflect.Slice {
+			return
+		}
+		for i := 0; i < list.Len(); i++ {
+			item := list.Index(i)
+			r.walk(item, segments, cursor.item(i, item), dryRun, report)
+		}
+	} else {
+		r.walk(child, segments, cursor, dryRun, report)
+	}
+	if commit != nil && !dryRun && len(*report) > before {
+		commit(child)
+	}
+}
+
+func (r compiledRedactionRule) redact(v reflect.Value, cursor redactionCursor, dryRun bool, report *[]RedactedField) {
+	target := v
+	for target.Kind() == reflect.Ptr || target.Kind() == reflect.Interface {
+		if target.IsNil() {
+			return
+		}
+		target = target.Elem()
+	}
+	var current string
+	if target.Kind() == reflect.String {
+		current = target.String()
+	} else if target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8 {
+		current = string(target.Bytes())
+	} else {
+		return
+	}
+	if current == "" || current == RedactedValue {
+		return
+	}
+	if r.key != nil && !r.key.MatchString(cursor.key) && (cursor.entry == "" || !r.key.MatchString(cursor.entry)) {
+		return
+	}
+	if r.value != nil && !r.value.MatchString(current) {
+		return
+	}
+	*report = append(*report, RedactedField{Path: cursor.path, Rule: r.rule})
+	if dryRun {
+		return
+	}
+	if v.Kind() == reflect.Interface {
+		if v.CanSet() {
+			v.Set(reflect.ValueOf(RedactedValue))
+		}
+		return
+	}
+	if !target.CanSet() {
+		return
+	}
+	if target.Kind() == reflect.String {
+		target.SetString(RedactedValue)
+	} else {
+		target.SetBytes([]byte(RedactedValue))
+	}
+}
+
+func (c redactionCursor) child(name string) redactionCursor {
+	path := name
+	if strings.Contains(name, ".") {
+		path = "[" + strconv.Quote(name) + "]"
+	} else if c.path != "" {
+		path = "." + name
+	}
+	return redactionCursor{path: c.path + path, key: name, entry: c.entry}
+}
+
+func (c redactionCursor) item(index int, item reflect.Value) redactionCursor {
+	entry := c.entry
+	if name := entryNameOf(item); name != "" {
+		entry = name
+	}
+	return redactionCursor{path: c.path + "[" + strconv.Itoa(index) + "]", key: c.key, entry: entry}
+}
+
+// entryNameOf returns the name field of a list entry, e.g. the name of an EnvVar.
+func entryNameOf(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return ""
+		}
+		name := v.MapIndex(reflect.ValueOf("name"))
+		if name.IsValid() {
+			if s, ok := name.Interface().(string); ok {
+				return s
+			}
+		}
+	case reflect.Struct:
+		for _, field := range jsonFieldsOf(v) {
+			if field.name == "name" && field.value.Kind() == reflect.String {
+				return field.value.String()
+			}
+		}
+	}
+	return ""
+}
+
+type jsonField struct {
+	name  string
+	value reflect.Value
+}
+
+// jsonFieldsOf returns struct fields by their json names, flattening inline embedded structs.
+func jsonFieldsOf(v reflect.Value) []jsonField {
+	fields := []jsonField{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFieldsOf(embedded)...)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{name: name, value: v.Field(i)})
+	}
+	return fields
+}
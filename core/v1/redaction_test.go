@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"sort"
+	"testing"
+)
+
+func TestDefaultRedactionRules(t *testing.T) {
+	lastApplied := "kubectl.kubernetes.io/last-applied-configuration"
+	podSpec := func() PodSpec {
+		return PodSpec{
+			Containers: []Container{{
+				Name: "web",
+				Env: []EnvVar{
+					{Name: "DB_PASSWORD", Value: "hunter2"},
+					{Name: "API_TOKEN", Value: "abc"},
+					{Name: "LOG_LEVEL", Value: "debug"},
+				},
+			}},
+			InitContainers: []Container{{
+				Name: "migrate",
+				Env:  []EnvVar{{Name: "SECRET_KEY", Value: "xyz"}},
+			}},
+		}
+	}
+	testCases := []struct {
+		name     string
+		kind     enums.RESOURCE_TYPE
+		obj      interface{}
+		redacted []string
+		check    func(t *testing.T, obj interface{})
+	}{
+		{
+			name: "secret data and string data",
+			kind: enums.SECRET,
+			obj: &K8sSecret{
+				Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("hunter2")},
+				StringData: map[string]string{"config": "plain"},
+			},
+			redacted: []string{"data.password", "data.username", "stringData.config"},
+			check: func(t *testing.T, obj interface{}) {
+				secret := obj.(*K8sSecret)
+				for key, value := range secret.Data {
+					if string(value) != RedactedValue {
+						t.Errorf("data.%s = %q, want redacted", key, value)
+					}
+				}
+				if secret.StringData["config"] != RedactedValue {
+					t.Errorf("stringData.config = %q, want redacted", secret.StringData["config"])
+				}
+			},
+		},
+		{
+			name: "config map keys looking like credentials",
+			kind: enums.CONFIG_MAP,
+			obj: &K8sConfigMap{
+				Data: map[string]string{"db_password": "hunter2", "log_level": "debug"},
+			},
+			redacted: []string{"data.db_password"},
+			check: func(t *testing.T, obj interface{}) {
+				configMap := obj.(*K8sConfigMap)
+				if configMap.Data["db_password"] != RedactedValue {
+					t.Errorf("data.db_password = %q, want redacted", configMap.Data["db_password"])
+				}
+				if configMap.Data["log_level"] != "debug" {
+					t.Errorf("data.log_level = %q, want debug", configMap.Data["log_level"])
+				}
+			},
+		},
+		{
+			name:     "pod env values",
+			kind:     enums.POD,
+			obj:      &K8sPod{Spec: podSpec()},
+			redacted: []string{"spec.containers[0].env[0].value", "spec.containers[0].env[1].value", "spec.initContainers[0].env[0].value"},
+			check: func(t *testing.T, obj interface{}) {
+				pod := obj.(*K8sPod)
+				env := pod.Spec.Containers[0].Env
+				if env[0].Value != RedactedValue || env[1].Value != RedactedValue {
+					t.Errorf("credential env values = %q, %q, want redacted", env[0].Value, env[1].Value)
+				}
+				if env[2].Value != "debug" {
+					t.Errorf("LOG_LEVEL = %q, want debug", env[2].Value)
+				}
+				if pod.Spec.InitContainers[0].Env[0].Value != RedactedValue {
+					t.Errorf("init container SECRET_KEY = %q, want redacted", pod.Spec.InitContainers[0].Env[0].Value)
+				}
+			},
+		},
+		{
+			name:     "deployment pod template env values",
+			kind:     enums.DEPLOYMENT,
+			obj:      &K8sDeployment{Spec: DeploymentSpec{Template: PodTemplateSpec{Spec: podSpec()}}},
+			redacted: []string{"spec.template.spec.containers[0].env[0].value", "spec.template.spec.containers[0].env[1].value", "spec.template.spec.initContainers[0].env[0].value"},
+			check: func(t *testing.T, obj interface{}) {
+				env := obj.(*K8sDeployment).Spec.Template.Spec.Containers[0].Env
+				if env[2].Value != "debug" {
+					t.Errorf("LOG_LEVEL = %q, want debug", env[2].Value)
+				}
+			},
+		},
+		{
+			name: "last applied configuration of any kind",
+			kind: enums.SERVICE,
+			obj: &K8sService{ObjectMeta: ObjectMeta{Annotations: map[string]string{
+				lastApplied: `{"kind":"Service"}`,
+				"team":      "payments",
+			}}},
+			redacted: []string{`metadata.annotations["` + lastApplied + `"]`},
+			check: func(t *testing.T, obj interface{}) {
+				annotations := obj.(*K8sService).Annotations
+				if annotations[lastApplied] != RedactedValue {
+					t.Errorf("last applied configuration = %q, want redacted", annotations[lastApplied])
+				}
+				if annotations["team"] != "payments" {
+					t.Errorf("team = %q, want payments", annotations["team"])
+				}
+			},
+		},
+	}
+	engine := newRedactionEngine(defaultRedactionRules)
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			report := engine.apply(testCase.kind, testCase.obj, false)
+			paths := []string{}
+			for _, each := range report {
+				paths = append(paths, each.Path)
+			}
+			sort.Strings(paths)
+			if len(paths) != len(testCase.redacted) {
+				t.Fatalf("redacted %v, want %v", paths, testCase.redacted)
+			}
+			for i := range paths {
+				if paths[i] != testCase.redacted[i] {
+					t.Fatalf("redacted %v, want %v", paths, testCase.redacted)
+				}
+			}
+			testCase.check(t, testCase.obj)
+		})
+	}
+}
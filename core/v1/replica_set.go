@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type ReplicaSet struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sReplicaSet `bson:"obj" json:"obj"`
 	AgentName          string        `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj ReplicaSet) deleteAll() error {
@@ -43,6 +45,7 @@ func NewReplicaSet() KubeObject {
 
 func (obj ReplicaSet) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.REPLICASET, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(ReplicaSetCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -97,7 +100,9 @@ func (obj ReplicaSet) findByNameAndNamespaceAndCompanyId() K8sReplicaSet {
 	return temp.Obj
 }
 
-func (obj ReplicaSet) Delete(agent string) error {
+func (obj ReplicaSet) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.REPLICASET, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -106,7 +111,7 @@ func (obj ReplicaSet) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ReplicaSetCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -123,6 +128,7 @@ func (obj ReplicaSet) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.REPLICASET, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -0,0 +1,232 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultPageLimit refers to page size when none is requested.
+	DefaultPageLimit = 20
+	// MaxPageLimit refers to largest allowed page size.
+	MaxPageLimit = 500
+)
+
+// ErrUnknownResource returned for unsupported resource types.
+var ErrUnknownResource = errors.New("unknown resource type")
+
+// ErrResourceNotFound returned when a stored object does not exist.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// StoredObject stored kube object along with its storage metadata.
+type StoredObject struct {
+	AgentName       string     `json:"agent_name" bson:"agent_name"`
+	Unverified      bool       `json:"unverified,omitempty" bson:"unverified"`
+	UnverifiedSince *time.Time `json:"unverified_since,omitempty" bson:"unverified_since"`
+	Orphaned        bool       `json:"orphaned,omitempty" bson:"orphaned"`
+	OrphanedSince   *time.Time `json:"orphaned_since,omitempty" bson:"orphaned_since"`
+	Tombstone       `bson:",inline"`
+	Obj             json.RawMessage `json:"obj" bson:"-"`
+}
+
+// ResourceQuery scope and page of a stored objects query.
+type ResourceQuery struct {
+	Type      enums.RESOURCE_TYPE
+	CompanyId string
+	AgentName string
+	Namespace string
+	// LabelSelector kubectl style label selector, e.g. app=web,tier in (fe,be)
+	LabelSelector string
+	// FieldSelector kubectl style field selector, e.g. status.phase=Running
+	FieldSelector string
+	// IncludeDeleted includes tombstones of deleted objects.
+	IncludeDeleted bool
+	// Orphaned limits to objects whose owners no longer exist.
+	Orphaned bool
+	// IncludeUnlabeled includes objects carrying no company label, for agents known to belong to the company.
+	IncludeUnlabeled bool
+	Page             int64
+	Limit            int64
+}
+
+func (q ResourceQuery) conditions(descriptor ResourceDescriptor) ([]bson.M, error) {
+	conditions := []bson.M{}
+	if !q.IncludeDeleted {
+		conditions = append(conditions, notDeleted)
+	}
+	if q.Orphaned {
+		conditions = append(conditions, bson.M{"orphaned": true})
+	}
+	if q.CompanyId != "" && q.IncludeUnlabeled {
+		conditions = append(conditions, bson.M{"obj.metadata.labels.company": bson.M{"$in": []interface{}{q.CompanyId, nil, ""}}})
+	} else if q.CompanyId != "" {
+		conditions = append(conditions, bson.M{"obj.metadata.labels.company": q.CompanyId})
+	}
+	if q.AgentName != "" {
+		conditions = append(conditions, bson.M{"agent_name": q.AgentName})
+	}
+	if q.Namespace != "" && descriptor.Namespaced {
+		conditions = append(conditions, bson.M{"obj.metadata.namespace": q.Namespace})
+	}
+	if q.LabelSelector != "" {
+		selector, err := ParseLabelSelector(q.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		labelConditions, err := selector.Filter("obj.metadata.labels")
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, labelConditions...)
+	}
+	if q.FieldSelector != "" {
+		fieldConditions, err := ParseFieldSelector(descriptor.Type, q.FieldSelector)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, fieldConditions...)
+	}
+	return conditions, nil
+}
+
+// andFilter joins conditions into a single filter.
+func andFilter(conditions []bson.M) bson.M {
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+// decodeStoredObject decodes a raw document of the resource type into StoredObject.
+func decodeStoredObject(object enums.RESOURCE_TYPE, raw bson.Raw) (StoredObject, error) {
+	var stored StoredObject
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		return StoredObject{}, err
+	}
+	kubeObject := GetObject(object)
+	if err := bson.Unmarshal(raw, kubeObject); err != nil {
+		return StoredObject{}, err
+	}
+	data, err := json.Marshal(kubeObject)
+	if err != nil {
+		return StoredObject{}, err
+	}
+	var body struct {
+		Obj json.RawMessage `json:"obj"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return StoredObject{}, err
+	}
+	stored.Obj = body.Obj
+	return stored, nil
+}
+
+// FindResources returns a page of stored objects and total count of objects matching the query.
+func FindResources(q ResourceQuery) ([]StoredObject, int64, error) {
+	descriptor, ok := GetResourceDescriptor(q.Type)
+	if !ok {
+		return nil, 0, ErrUnknownResource
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	} else if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	conditions, err := q.conditions(descriptor)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter := andFilter(conditions)
+	coll := db.GetDmManager().Db.Collection(descriptor.Collection)
+	total, err := coll.CountDocuments(db.GetDmManager().Ctx, filter)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "obj.metadata.namespace", Value: 1}, {Key: "obj.metadata.name", Value: 1}}).
+		SetSkip(q.Page * q.Limit).
+		SetLimit(q.Limit)
+	curser, err := coll.Find(db.GetDmManager().Ctx, filter, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	defer curser.Close(context.TODO())
+	objects := []StoredObject{}
+	for curser.Next(context.TODO()) {
+		stored, err := decodeStoredObject(q.Type, curser.Current)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		objects = append(objects, stored)
+	}
+	return objects, total, nil
+}
+
+// FindResource returns a stored object by name.
+func FindResource(q ResourceQuery, name string) (StoredObject, error) {
+	descriptor, ok := GetResourceDescriptor(q.Type)
+	if !ok {
+		return StoredObject{}, ErrUnknownResource
+	}
+	conditions, err := q.conditions(descriptor)
+	if err != nil {
+		return StoredObject{}, err
+	}
+	filter := andFilter(append(conditions, bson.M{"obj.metadata.name": name}))
+	coll := db.GetDmManager().Db.Collection(descriptor.Collection)
+	raw, err := coll.FindOne(db.GetDmManager().Ctx, filter).DecodeBytes()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return StoredObject{}, ErrResourceNotFound
+		}
+		log.Println("[ERROR]", err)
+		return StoredObject{}, err
+	}
+	return decodeStoredObject(q.Type, raw)
+}
+
+// findLiveObjects returns decoded stored objects of a resource type reported by an agent, excluding tombstones.
+// Namespace and company are optional.
+func findLiveObjects(object enums.RESOURCE_TYPE, companyId, agent, namespace string) ([]KubeObject, error) {
+	descriptor, ok := GetResourceDescriptor(object)
+	if !ok {
+		return nil, ErrUnknownResource
+	}
+	conditions := []bson.M{{"agent_name": agent}, notDeleted}
+	if companyId != "" {
+		conditions = append(conditions, bson.M{"obj.metadata.labels.company": companyId})
+	}
+	if namespace != "" && descriptor.Namespaced {
+		conditions = append(conditions, bson.M{"obj.metadata.namespace": namespace})
+	}
+	curser, err := db.GetDmManager().Db.Collection(descriptor.Collection).Find(db.GetDmManager().Ctx, andFilter(conditions))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, err
+	}
+	defer curser.Close(context.TODO())
+	objects := []KubeObject{}
+	for curser.Next(context.TODO()) {
+		kubeObject := GetObject(object)
+		if err := bson.Unmarshal(curser.Current, kubeObject); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		objects = append(objects, kubeObject)
+	}
+	return objects, nil
+}
@@ -0,0 +1,281 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"time"
+)
+
+const RevisionCollection = "revisionCollection"
+
+// ErrRevisionNotFound returned when an object has no revision at the requested time.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision applied version of a kube object. Revisions are append only, one is recorded for every applied
+// ADD, UPDATE and DELETE, the latter carrying the last known state of the object.
+type Revision struct {
+	Kind            enums.RESOURCE_TYPE `json:"kind" bson:"kind"`
+	CompanyId       string              `json:"company" bson:"company"`
+	AgentName       string              `json:"agent_name" bson:"agent_name"`
+	Namespace       string              `json:"namespace" bson:"namespace"`
+	Name            string              `json:"name" bson:"name"`
+	UID             string              `json:"uid" bson:"uid"`
+	ResourceVersion string              `json:"resource_version" bson:"resource_version"`
+	Command         enums.Command       `json:"command" bson:"command"`
+	Offset          int                 `json:"offset" bson:"offset"`
+	RecordedAt      time.Time           `json:"recorded_at" bson:"recorded_at"`
+	// Changes field changes from the previous version, set for UPDATE.
+	Changes []FieldChange          `json:"changes,omitempty" bson:"changes,omitempty"`
+	Obj     map[string]interface{} `json:"obj" bson:"obj"`
+	// ExpireAt time the revision is removed at, unset if revisions are kept forever.
+	ExpireAt *time.Time `json:"-" bson:"expire_at,omitempty"`
+}
+
+// RevisionQuery scope and page of a revisions query.
+type RevisionQuery struct {
+	Type      enums.RESOURCE_TYPE
+	CompanyId string
+	AgentName string
+	Namespace string
+	Name      string
+	From      *time.Time
+	To        *time.Time
+	Page      int64
+	Limit     int64
+}
+
+func (q RevisionQuery) filter() bson.M {
+	conditions := []bson.M{
+		{"kind": q.Type},
+		{"agent_name": q.AgentName},
+		{"namespace": q.Namespace},
+	}
+	if q.Name != "" {
+		conditions = append(conditions, bson.M{"name": q.Name})
+	}
+	if q.CompanyId != "" {
+		conditions = append(conditions, bson.M{"company": q.CompanyId})
+	}
+	if q.From != nil {
+		conditions = append(conditions, bson.M{"recorded_at": bson.M{"$gte": *q.From}})
+	}
+	if q.To != nil {
+		conditions = append(conditions, bson.M{"recorded_at": bson.M{"$lte": *q.To}})
+	}
+	return andFilter(conditions)
+}
+
+// decode decodes the recorded object into obj, a typed kube object.
+func (r Revision) decode(obj interface{}) error {
+	data, err := json.Marshal(r.Obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, obj)
+}
+
+// findRevisionsInRange returns every revision matching the query, oldest first.
+func findRevisionsInRange(filter bson.M) ([]Revision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "recorded_at", Value: 1}, {Key: "_id", Value: 1}})
+	curser, err := db.GetDmManager().Db.Collection(RevisionCollection).Find(db.GetDmManager().Ctx, filter, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, err
+	}
+	defer curser.Close(context.TODO())
+	revisions := []Revision{}
+	for curser.Next(context.TODO()) {
+		var revision Revision
+		if err := curser.Decode(&revision); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// recordRevision appends applied version of the object to its history.
+func recordRevision(event AppliedKubeEvent) {
+	revision := Revision{
+		Kind:            event.Type,
+		CompanyId:       event.CompanyId,
+		AgentName:       event.AgentName,
+		Namespace:       event.Meta.Namespace,
+		Name:            event.Meta.Name,
+		UID:             string(event.Meta.UID),
+		ResourceVersion: event.Meta.ResourceVersion,
+		Command:         event.Command,
+		Offset:          event.Offset,
+		RecordedAt:      event.AppliedAt,
+		Changes:         event.Changes,
+		Obj:             event.Obj,
+	}
+	if retention := revisionRetention(event.Type, event.CompanyId); retention > 0 {
+		expireAt := event.AppliedAt.Add(retention)
+		revision.ExpireAt = &expireAt
+	}
+	_, err := db.GetDmManager().Db.Collection(RevisionCollection).InsertOne(db.GetDmManager().Ctx, revision)
+	if err != nil {
+		log.Println("[ERROR] Insert document:", err.Error())
+	}
+}
+
+// revisionRetention returns how long revisions of a kind are kept, 0 if they are kept forever.
+func revisionRetention(object enums.RESOURCE_TYPE, companyId string) time.Duration {
+	if object == enums.EVENT {
+		return EventRetention(companyId)
+	}
+	return config.RevisionRetention
+}
+
+// EnsureRevisionIndexes creates lookup and ttl indexes of revisions and sets expiry of revisions recorded before
+// retention existed.
+func EnsureRevisionIndexes() {
+	coll := db.GetDmManager().Db.Collection(RevisionCollection)
+	_, err := coll.Indexes().CreateMany(db.GetDmManager().Ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "agent_name", Value: 1},
+				{Key: "kind", Value: 1},
+				{Key: "namespace", Value: 1},
+				{Key: "name", Value: 1},
+				{Key: "recorded_at", Value: 1},
+			},
+		},
+		{
+			Keys:    bson.D{{Key: "expire_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create revision indexes:", err.Error())
+	}
+	now := time.Now().UTC()
+	if config.RevisionRetention > 0 {
+		_, err = coll.UpdateMany(db.GetDmManager().Ctx, andFilter([]bson.M{{"expire_at": bson.M{"$exists": false}}, {"kind": bson.M{"$ne": enums.EVENT}}}), bson.M{"$set": bson.M{"expire_at": now.Add(config.RevisionRetention)}})
+		if err != nil {
+			log.Println("[ERROR] Failed to set expiry of revisions:", err.Error())
+		}
+	}
+	_, err = coll.UpdateMany(db.GetDmManager().Ctx, andFilter([]bson.M{{"expire_at": bson.M{"$exists": false}}, {"kind": enums.EVENT}}), bson.M{"$set": bson.M{"expire_at": now.Add(config.EventRetention)}})
+	if err != nil {
+		log.Println("[ERROR] Failed to set expiry of event revisions:", err.Error())
+	}
+}
+
+// FindRevisions returns a page of revisions of an object, latest first, and total count of its revisions.
+func FindRevisions(q RevisionQuery) ([]Revision, int64, error) {
+	if _, ok := GetResourceDescriptor(q.Type); !ok {
+		return nil, 0, ErrUnknownResource
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	} else if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	coll := db.GetDmManager().Db.Collection(RevisionCollection)
+	total, err := coll.CountDocuments(db.GetDmManager().Ctx, q.filter())
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "recorded_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(q.Page * q.Limit).
+		SetLimit(q.Limit)
+	curser, err := coll.Find(db.GetDmManager().Ctx, q.filter(), opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	defer curser.Close(context.TODO())
+	revisions := []Revision{}
+	for curser.Next(context.TODO()) {
+		var revision Revision
+		if err := curser.Decode(&revision); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, total, nil
+}
+
+// FindRevisionAsOf returns the revision of an object that was current at the given time.
+// ErrRevisionNotFound is returned if the object did not exist at that time.
+func FindRevisionAsOf(q RevisionQuery, asOf time.Time) (Revision, error) {
+	if _, ok := GetResourceDescriptor(q.Type); !ok {
+		return Revision{}, ErrUnknownResource
+	}
+	q.From = nil
+	q.To = &asOf
+	opts := options.FindOne().SetSort(bson.D{{Key: "recorded_at", Value: -1}, {Key: "_id", Value: -1}})
+	var revision Revision
+	err := db.GetDmManager().Db.Collection(RevisionCollection).FindOne(db.GetDmManager().Ctx, q.filter(), opts).Decode(&revision)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Revision{}, ErrRevisionNotFound
+		}
+		log.Println("[ERROR]", err)
+		return Revision{}, err
+	}
+	if revision.Command == enums.DELETE {
+		return Revision{}, ErrRevisionNotFound
+	}
+	return revision, nil
+}
+
+// FindNamespaceAsOf returns revisions of every object of a namespace that were current at the given time,
+// optionally limited to a resource type.
+func FindNamespaceAsOf(companyId, agent, namespace string, object enums.RESOURCE_TYPE, asOf time.Time) ([]Revision, error) {
+	conditions := []bson.M{
+		{"agent_name": agent},
+		{"namespace": namespace},
+		{"recorded_at": bson.M{"$lte": asOf}},
+	}
+	if companyId != "" {
+		conditions = append(conditions, bson.M{"company": companyId})
+	}
+	if object != "" {
+		if _, ok := GetResourceDescriptor(object); !ok {
+			return nil, ErrUnknownResource
+		}
+		conditions = append(conditions, bson.M{"kind": object})
+	}
+	pipeline := []bson.M{
+		{"$match": andFilter(conditions)},
+		{"$sort": bson.D{{Key: "recorded_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{"$group": bson.M{"_id": bson.M{"kind": "$kind", "name": "$name"}, "revision": bson.M{"$first": "$$ROOT"}}},
+		{"$replaceRoot": bson.M{"newRoot": "$revision"}},
+		{"$match": bson.M{"command": bson.M{"$ne": enums.DELETE}}},
+		{"$sort": bson.D{{Key: "kind", Value: 1}, {Key: "name", Value: 1}}},
+	}
+	curser, err := db.GetDmManager().Db.Collection(RevisionCollection).Aggregate(db.GetDmManager().Ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, err
+	}
+	defer curser.Close(context.TODO())
+	revisions := []Revision{}
+	for curser.Next(context.TODO()) {
+		var revision Revision
+		if err := curser.Decode(&revision); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -24,6 +25,7 @@ type Role struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sRole `bson:"obj" json:"obj"`
 	AgentName          string  `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Role) deleteAll() error {
@@ -43,6 +45,7 @@ func NewRole() KubeObject {
 
 func (obj Role) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.ROLE, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(RoleCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj Role) findByNameAndNamespaceAndCompanyId() K8sRole {
 	return temp.Obj
 }
 
-func (obj Role) Delete(agent string) error {
+func (obj Role) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.ROLE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
@@ -107,7 +112,7 @@ func (obj Role) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(RoleCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -124,6 +129,7 @@ func (obj Role) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.ROLE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.name": obj.Obj.Name},
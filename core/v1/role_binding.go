@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type RoleBinding struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sRoleBinding `bson:"obj" json:"obj"`
 	AgentName          string         `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj RoleBinding) deleteAll() error {
@@ -44,6 +46,7 @@ func NewRoleBinding() KubeObject {
 
 func (obj RoleBinding) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.ROLE_BINDING, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(RoleBindingCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -98,7 +101,9 @@ func (obj RoleBinding) findByNameAndNamespaceAndCompanyId() K8sRoleBinding {
 	return temp.Obj
 }
 
-func (obj RoleBinding) Delete(agent string) error {
+func (obj RoleBinding) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.ROLE_BINDING, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -107,7 +112,7 @@ func (obj RoleBinding) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(RoleBindingCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -124,6 +129,7 @@ func (obj RoleBinding) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.ROLE_BINDING, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
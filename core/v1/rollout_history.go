@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// RevisionAnnotation rollout revision a deployment stamps on its replicasets.
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+	// ChangeCauseAnnotation cause of a rollout, copied from the deployment to its replicasets.
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// TemplateImage image of a container of a pod template.
+type TemplateImage struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+}
+
+// RolloutRevision revision of a deployment rollout, reconstructed from recorded versions of its replicasets.
+type RolloutRevision struct {
+	Revision    int64           `json:"revision"`
+	ReplicaSet  string          `json:"replica_set"`
+	Images      []TemplateImage `json:"images"`
+	ChangeCause string          `json:"change_cause,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// ScaledUpAt first time the replicaset was recorded scaled up for the revision.
+	ScaledUpAt *time.Time `json:"scaled_up_at,omitempty"`
+	// ScaledDownAt first time the replicaset was recorded scaled down to zero after scaling up for the revision.
+	ScaledDownAt *time.Time `json:"scaled_down_at,omitempty"`
+	// Current set for the revision the deployment currently runs.
+	Current bool `json:"current"`
+	// GarbageCollected set if the replicaset is deleted.
+	GarbageCollected bool `json:"garbage_collected"`
+}
+
+// ownedBy returns true if the deployment, by uid if known otherwise by name, owns the replicaset.
+func (r K8sReplicaSet) ownedBy(deployment string, uid UID) bool {
+	for _, owner := range r.OwnerReferences {
+		if owner.Kind != "Deployment" {
+			continue
+		}
+		if uid != "" && owner.UID == uid || uid == "" && owner.Name == deployment {
+			return true
+		}
+	}
+	return false
+}
+
+// images returns images of containers of the pod template.
+func (t PodTemplateSpec) images() []TemplateImage {
+	images := []TemplateImage{}
+	for _, container := range t.Spec.Containers {
+		images = append(images, TemplateImage{Container: container.Name, Image: container.Image})
+	}
+	return images
+}
+
+// FindRolloutHistory returns rollout history of a deployment, oldest revision first. History is reconstructed from
+// recorded versions of its replicasets, so revisions survive garbage collection of replicasets. A replicaset reused by
+// a rollback is reported once for every revision it ran.
+func FindRolloutHistory(companyId, agent, namespace, name string) ([]RolloutRevision, error) {
+	var deployment K8sDeployment
+	found := false
+	latest, err := FindRevisionAsOf(RevisionQuery{Type: enums.DEPLOYMENT, CompanyId: companyId, AgentName: agent, Namespace: namespace, Name: name}, time.Now().UTC())
+	if err == nil {
+		found = latest.decode(&deployment) == nil
+	} else if err != ErrRevisionNotFound {
+		return nil, err
+	}
+	revisions, err := findRevisionsInRange(RevisionQuery{Type: enums.REPLICASET, CompanyId: companyId, AgentName: agent, Namespace: namespace}.filter())
+	if err != nil {
+		return nil, err
+	}
+	history := []*RolloutRevision{}
+	entries := make(map[string]*RolloutRevision)
+	for _, revision := range revisions {
+		var replicaSet K8sReplicaSet
+		if err := revision.decode(&replicaSet); err != nil || !replicaSet.ownedBy(name, deployment.UID) {
+			continue
+		}
+		number, err := strconv.ParseInt(replicaSet.Annotations[RevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		key := string(replicaSet.UID) + "/" + strconv.FormatInt(number, 10)
+		entry, ok := entries[key]
+		if !ok {
+			entry = &RolloutRevision{
+				Revision:   number,
+				ReplicaSet: replicaSet.Name,
+				Images:     replicaSet.Spec.Template.images(),
+				CreatedAt:  replicaSet.CreationTimestamp.Time,
+			}
+			entries[key] = entry
+			history = append(history, entry)
+		}
+		entry.ChangeCause = replicaSet.Annotations[ChangeCauseAnnotation]
+		recordedAt := revision.RecordedAt
+		if revision.Command == enums.DELETE {
+			entry.GarbageCollected = true
+			if entry.ScaledUpAt != nil && entry.ScaledDownAt == nil {
+				entry.ScaledDownAt = &recordedAt
+			}
+			continue
+		}
+		entry.GarbageCollected = false
+		replicas := int32(1)
+		if replicaSet.Spec.Replicas != nil {
+			replicas = *replicaSet.Spec.Replicas
+		}
+		if replicas > 0 && entry.ScaledUpAt == nil {
+			entry.ScaledUpAt = &recordedAt
+		} else if replicas == 0 && entry.ScaledUpAt != nil && entry.ScaledDownAt == nil {
+			entry.ScaledDownAt = &recordedAt
+		}
+	}
+	if !found && len(history) == 0 {
+		return nil, ErrResourceNotFound
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+	current, _ := strconv.ParseInt(deployment.Annotations[RevisionAnnotation], 10, 64)
+	rollouts := make([]RolloutRevision, 0, len(history))
+	for _, entry := range history {
+		entry.Current = found && entry.Revision == current
+		rollouts = append(rollouts, *entry)
+	}
+	return rollouts, nil
+}
@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// progressDeadlineExceeded reason of the progressing condition of a deployment whose rollout is stuck.
+const progressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+// rolloutNotificationAttempts refers to how many times a notification is posted before it is dropped.
+const rolloutNotificationAttempts = 3
+
+// RolloutNotification rollout result of a workload, posted back to the ci pipeline.
+type RolloutNotification struct {
+	Status     enums.ROLLOUT_STATUS `json:"status"`
+	Kind       enums.RESOURCE_TYPE  `json:"kind"`
+	CompanyId  string               `json:"company"`
+	AgentName  string               `json:"agent_name"`
+	Namespace  string               `json:"namespace"`
+	Name       string               `json:"name"`
+	Generation int64                `json:"generation"`
+	// ProcessId pipeline process id stamped on the workload.
+	ProcessId string    `json:"process_id"`
+	Reason    string    `json:"reason,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// rolloutState state of a rollout of a workload.
+type rolloutState struct {
+	meta     ObjectMeta
+	finished bool
+	failed   bool
+	reason   string
+	message  string
+}
+
+// rolloutStateOf returns rollout state of a deployment, statefulset or daemonset.
+func rolloutStateOf(kubeObject KubeObject) (rolloutState, bool) {
+	switch object := kubeObject.(type) {
+	case *Deployment:
+		deployment := object.Obj
+		state := rolloutState{meta: deployment.ObjectMeta}
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == DeploymentProgressing && condition.Status == ConditionFalse && condition.Reason == progressDeadlineExceeded {
+				state.failed = true
+				state.reason = condition.Reason
+				state.message = condition.Message
+				return state, true
+			}
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		status := deployment.Status
+		state.finished = status.ObservedGeneration >= deployment.Generation && status.UpdatedReplicas == replicas &&
+			status.Replicas == replicas && status.AvailableReplicas == replicas
+		return state, true
+	case *StatefulSet:
+		statefulSet := object.Obj
+		state := rolloutState{meta: statefulSet.ObjectMeta}
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		status := statefulSet.Status
+		state.finished = status.ObservedGeneration >= statefulSet.Generation && status.ReadyReplicas == replicas &&
+			status.UpdatedReplicas == replicas && (status.UpdateRevision == "" || status.CurrentRevision == status.UpdateRevision)
+		return state, true
+	case *DaemonSet:
+		daemonSet := object.Obj
+		status := daemonSet.Status
+		state := rolloutState{meta: daemonSet.ObjectMeta}
+		state.finished = status.ObservedGeneration >= daemonSet.Generation && status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+			status.NumberAvailable == status.DesiredNumberScheduled
+		return state, true
+	}
+	return rolloutState{}, false
+}
+
+// pipelineIdentifier returns value of a pipeline identifier stamped on an object, labels take precedence over annotations.
+func pipelineIdentifier(meta ObjectMeta, key string) string {
+	if key == "" {
+		return ""
+	}
+	if value, ok := meta.Labels[key]; ok {
+		return value
+	}
+	return meta.Annotations[key]
+}
+
+// notifyRolloutResult posts a rollout-finished or rollout-failed notification when an update of a deployment,
+// statefulset or daemonset completes or fails its rollout. Only transitions are notified, so repeated status updates of
+// a finished rollout are not. Workloads not stamped with a pipeline process id are ignored.
+func notifyRolloutResult(event AppliedKubeEvent) {
+	if config.RolloutNotificationUrl == "" || event.Command != enums.UPDATE || event.OldObject == nil {
+		return
+	}
+	state, ok := rolloutStateOf(event.Object)
+	if !ok {
+		return
+	}
+	processId := pipelineIdentifier(state.meta, config.PipelineProcessIdKey)
+	if processId == "" {
+		return
+	}
+	old, _ := rolloutStateOf(event.OldObject)
+	notification := RolloutNotification{
+		Kind:       event.Type,
+		CompanyId:  event.CompanyId,
+		AgentName:  event.AgentName,
+		Namespace:  state.meta.Namespace,
+		Name:       state.meta.Name,
+		Generation: state.meta.Generation,
+		ProcessId:  processId,
+		Reason:     state.reason,
+		Message:    state.message,
+		Time:       event.AppliedAt,
+	}
+	if state.failed && !old.failed {
+		notification.Status = enums.ROLLOUT_FAILED
+	} else if state.finished && !old.finished {
+		notification.Status = enums.ROLLOUT_FINISHED
+	} else {
+		return
+	}
+	go postRolloutNotification(notification)
+}
+
+// postRolloutNotification posts the notification, retrying failed attempts with a linear backoff.
+func postRolloutNotification(notification RolloutNotification) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	for attempt := 1; attempt <= rolloutNotificationAttempts; attempt++ {
+		if err = postJSON(client, config.RolloutNotificationUrl, body); err == nil {
+			return
+		}
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+	log.Println("[ERROR] dropped", notification.Status, "notification of", notification.Kind, notification.Namespace+"/"+notification.Name, "process", notification.ProcessId+":", err)
+}
+
+func postJSON(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("unexpected response status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
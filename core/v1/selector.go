@@ -0,0 +1,209 @@
+package v1
+
+import (
+	"fmt"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selectableField stored path and value type of a field selector field.
+type selectableField struct {
+	path    string
+	boolean bool
+}
+
+var commonSelectableFields = map[string]selectableField{
+	"metadata.name":      {path: "obj.metadata.name"},
+	"metadata.namespace": {path: "obj.metadata.namespace"},
+	"metadata.uid":       {path: "obj.metadata.uid"},
+}
+
+var selectableFields = map[enums.RESOURCE_TYPE]map[string]selectableField{
+	enums.POD: {
+		"spec.nodeName":            {path: "obj.spec.nodeName"},
+		"spec.restartPolicy":       {path: "obj.spec.RestartPolicy"},
+		"spec.schedulerName":       {path: "obj.spec.schedulerName"},
+		"spec.serviceAccountName":  {path: "obj.spec.serviceAccountName"},
+		"status.phase":             {path: "obj.status.phase"},
+		"status.podIP":             {path: "obj.status.podIP"},
+		"status.hostIP":            {path: "obj.status.hostIP"},
+		"status.nominatedNodeName": {path: "obj.status.nominatedNodeName"},
+	},
+	enums.NODE: {
+		"spec.unschedulable": {path: "obj.spec.unschedulable", boolean: true},
+	},
+	enums.NAMESPACE: {
+		"status.phase": {path: "obj.status.NamespacePhase"},
+	},
+	enums.SECRET: {
+		"type": {path: "obj.type"},
+	},
+	enums.SERVICE: {
+		"spec.type":      {path: "obj.spec.type"},
+		"spec.clusterIP": {path: "obj.spec.clusterIP"},
+	},
+	enums.PERSISTENT_VOLUME: {
+		"status.phase":          {path: "obj.status.phase"},
+		"spec.storageClassName": {path: "obj.spec.storageClassName"},
+	},
+	enums.PERSISTENT_VOLUME_CLAIM: {
+		"status.phase":          {path: "obj.status.phase"},
+		"spec.storageClassName": {path: "obj.spec.storageClassName"},
+		"spec.volumeName":       {path: "obj.spec.volumeName"},
+	},
+	enums.EVENT: {
+		"involvedObject.kind":            {path: "obj.involvedObject.kind"},
+		"involvedObject.namespace":       {path: "obj.involvedObject.namespace"},
+		"involvedObject.name":            {path: "obj.involvedObject.name"},
+		"involvedObject.uid":             {path: "obj.involvedObject.uid"},
+		"involvedObject.apiVersion":      {path: "obj.involvedObject.apiVersion"},
+		"involvedObject.resourceVersion": {path: "obj.involvedObject.resourceVersion"},
+		"involvedObject.fieldPath":       {path: "obj.involvedObject.fieldpath"},
+		"reason":                         {path: "obj.reason"},
+		"reportingComponent":             {path: "obj.reportingController"},
+		"source":                         {path: "obj.source.component"},
+		"type":                           {path: "obj.type"},
+	},
+}
+
+// ParseLabelSelector parses kubectl style label selector, e.g. app=web,tier in (fe,be),!canary
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	result := LabelSelector{}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return result, fmt.Errorf("invalid label selector: %s", err.Error())
+	}
+	requirements, _ := parsed.Requirements()
+	for _, each := range requirements {
+		values := each.Values().List()
+		switch each.Operator() {
+		case selection.Equals, selection.DoubleEquals:
+			if result.MatchLabels == nil {
+				result.MatchLabels = make(map[string]string)
+			}
+			result.MatchLabels[each.Key()] = values[0]
+		case selection.NotEquals:
+			result.MatchExpressions = append(result.MatchExpressions, LabelSelectorRequirement{Key: each.Key(), Operator: LabelSelectorOpNotIn, Values: values})
+		case selection.In:
+			result.MatchExpressions = append(result.MatchExpressions, LabelSelectorRequirement{Key: each.Key(), Operator: LabelSelectorOpIn, Values: values})
+		case selection.NotIn:
+			result.MatchExpressions = append(result.MatchExpressions, LabelSelectorRequirement{Key: each.Key(), Operator: LabelSelectorOpNotIn, Values: values})
+		case selection.Exists:
+			result.MatchExpressions = append(result.MatchExpressions, LabelSelectorRequirement{Key: each.Key(), Operator: LabelSelectorOpExists})
+		case selection.DoesNotExist:
+			result.MatchExpressions = append(result.MatchExpressions, LabelSelectorRequirement{Key: each.Key(), Operator: LabelSelectorOpDoesNotExist})
+		default:
+			return result, fmt.Errorf("label selector operator %q is not supported", each.Operator())
+		}
+	}
+	return result, nil
+}
+
+// Filter returns mongo conditions matching objects whose labels, stored at path, satisfy the selector.
+func (s LabelSelector) Filter(path string) ([]bson.M, error) {
+	conditions := []bson.M{}
+	keys := make([]string, 0, len(s.MatchLabels))
+	for key := range s.MatchLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		conditions = append(conditions, labelCondition(path, key, LabelSelectorOpIn, []string{s.MatchLabels[key]}))
+	}
+	for _, each := range s.MatchExpressions {
+		switch each.Operator {
+		case LabelSelectorOpIn, LabelSelectorOpNotIn:
+			if len(each.Values) == 0 {
+				return nil, fmt.Errorf("label selector operator %s of key %q requires values", each.Operator, each.Key)
+			}
+		case LabelSelectorOpExists, LabelSelectorOpDoesNotExist:
+		default:
+			return nil, fmt.Errorf("label selector operator %q is not supported", each.Operator)
+		}
+		conditions = append(conditions, labelCondition(path, each.Key, each.Operator, each.Values))
+	}
+	return conditions, nil
+}
+
+// labelCondition builds condition of a single label requirement. Keys containing dots, e.g. app.kubernetes.io/name,
+// can not be addressed by dot notation, so they are matched through $objectToArray.
+func labelCondition(path, key string, operator LabelSelectorOperator, values []string) bson.M {
+	if !strings.ContainsAny(key, ".$") {
+		field := path + "." + key
+		switch operator {
+		case LabelSelectorOpIn:
+			return bson.M{field: bson.M{"$in": values}}
+		case LabelSelectorOpNotIn:
+			return bson.M{field: bson.M{"$nin": values}}
+		case LabelSelectorOpExists:
+			return bson.M{field: bson.M{"$exists": true}}
+		default:
+			return bson.M{field: bson.M{"$exists": false}}
+		}
+	}
+	entries := bson.M{"$objectToArray": bson.M{"$ifNull": []interface{}{"$" + path, bson.M{}}}}
+	cond := bson.M{"$eq": []interface{}{"$$label.k", key}}
+	if operator == LabelSelectorOpIn || operator == LabelSelectorOpNotIn {
+		cond = bson.M{"$and": []interface{}{cond, bson.M{"$in": []interface{}{"$$label.v", values}}}}
+	}
+	matched := bson.M{"$size": bson.M{"$filter": bson.M{"input": entries, "as": "label", "cond": cond}}}
+	if operator == LabelSelectorOpIn || operator == LabelSelectorOpExists {
+		return bson.M{"$expr": bson.M{"$gt": []interface{}{matched, 0}}}
+	}
+	return bson.M{"$expr": bson.M{"$eq": []interface{}{matched, 0}}}
+}
+
+// ParseFieldSelector parses kubectl style field selector of a resource type into mongo conditions,
+// e.g. status.phase=Running,spec.nodeName=n1
+func ParseFieldSelector(object enums.RESOURCE_TYPE, selector string) ([]bson.M, error) {
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector: %s", err.Error())
+	}
+	conditions := []bson.M{}
+	for _, each := range parsed.Requirements() {
+		field, ok := commonSelectableFields[each.Field]
+		if !ok {
+			field, ok = selectableFields[object][each.Field]
+		}
+		if !ok {
+			return nil, fmt.Errorf("field selector %q is not supported for %s, supported fields: %s", each.Field, object, strings.Join(SelectableFields(object), ", "))
+		}
+		var value interface{} = each.Value
+		if field.boolean {
+			parsedValue, err := strconv.ParseBool(each.Value)
+			if err != nil {
+				return nil, fmt.Errorf("field selector %q requires a boolean value", each.Field)
+			}
+			value = parsedValue
+		}
+		switch each.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			conditions = append(conditions, bson.M{field.path: value})
+		case selection.NotEquals:
+			conditions = append(conditions, bson.M{field.path: bson.M{"$ne": value}})
+		default:
+			return nil, fmt.Errorf("field selector operator %q is not supported", each.Operator)
+		}
+	}
+	return conditions, nil
+}
+
+// SelectableFields returns field selector fields supported by a resource type.
+func SelectableFields(object enums.RESOURCE_TYPE) []string {
+	result := []string{}
+	for field := range commonSelectableFields {
+		result = append(result, field)
+	}
+	for field := range selectableFields[object] {
+		result = append(result, field)
+	}
+	sort.Strings(result)
+	return result
+}
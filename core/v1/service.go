@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type Service struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sService `bson:"obj" json:"obj"`
 	AgentName          string     `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj Service) deleteAll() error {
@@ -44,6 +46,7 @@ func NewService() KubeObject {
 
 func (obj Service) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.SERVICE, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(ServiceCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -99,7 +102,9 @@ func (obj Service) findByNameAndNamespaceAndCompanyId() K8sService {
 	return temp.Obj
 }
 
-func (obj Service) Delete(agent string) error {
+func (obj Service) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.SERVICE, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -108,7 +113,7 @@ func (obj Service) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ServiceCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 
 	if err != nil {
 		log.Println("[ERROR]", err)
@@ -126,6 +131,7 @@ func (obj Service) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.SERVICE, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -26,6 +27,7 @@ type ServiceAccount struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sServiceAccount `bson:"obj" json:"obj"`
 	AgentName          string            `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj ServiceAccount) deleteAll() error {
@@ -45,6 +47,7 @@ func NewServiceAccount() KubeObject {
 
 func (obj ServiceAccount) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.SERVICE_ACCOUNT, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(ServiceAccountCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -100,7 +103,9 @@ func (obj ServiceAccount) findByNameAndNamespaceAndCompanyId() K8sServiceAccount
 	return temp.Obj
 }
 
-func (obj ServiceAccount) Delete(agent string) error {
+func (obj ServiceAccount) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.SERVICE_ACCOUNT, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -109,7 +114,7 @@ func (obj ServiceAccount) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(ServiceAccountCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -126,6 +131,7 @@ func (obj ServiceAccount) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.SERVICE_ACCOUNT, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sort"
+)
+
+// EndpointPort service port resolved to a container port of a pod.
+type EndpointPort struct {
+	Name       string `json:"name,omitempty"`
+	Port       int32  `json:"port"`
+	TargetPort int32  `json:"target_port"`
+	// TargetPortName named container port the service targets, resolved per endpoint.
+	TargetPortName string   `json:"target_port_name,omitempty"`
+	Protocol       Protocol `json:"protocol,omitempty"`
+}
+
+// ServiceEndpoint pod selected by a service.
+type ServiceEndpoint struct {
+	Pod      string         `json:"pod"`
+	IP       string         `json:"ip,omitempty"`
+	NodeName string         `json:"node_name,omitempty"`
+	Ready    bool           `json:"ready"`
+	Ports    []EndpointPort `json:"ports"`
+	// UnresolvedPorts named target ports the pod does not declare.
+	UnresolvedPorts []string `json:"unresolved_ports,omitempty"`
+}
+
+// ServiceEndpoints pods selected by a service, split by readiness.
+type ServiceEndpoints struct {
+	Service   string            `json:"service"`
+	Namespace string            `json:"namespace"`
+	Type      ServiceType       `json:"type,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Endpoints []ServiceEndpoint `json:"endpoints"`
+	NotReady  []ServiceEndpoint `json:"not_ready"`
+}
+
+// ObjectName namespaced name of a stored object.
+type ObjectName struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// ServiceRoutingReport endpoints of every service of an agent, along with services selecting no pod and pods no service selects.
+type ServiceRoutingReport struct {
+	Services []ServiceEndpoints `json:"services"`
+	// ServicesWithoutSelector services whose endpoints are not managed by selector, e.g. ExternalName services.
+	ServicesWithoutSelector  []ObjectName `json:"services_without_selector"`
+	ServicesSelectingNothing []ObjectName `json:"services_selecting_nothing"`
+	UnselectedPods           []ObjectName `json:"unselected_pods"`
+}
+
+// findServices returns stored services of an agent, namespace and company are optional.
+func findServices(companyId, agent, namespace string) ([]K8sService, error) {
+	kubeObjects, err := findLiveObjects(enums.SERVICE, companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	services := []K8sService{}
+	for _, each := range kubeObjects {
+		services = append(services, each.(*Service).Obj)
+	}
+	return services, nil
+}
+
+// findPods returns stored pods of an agent, namespace and company are optional.
+func findPods(companyId, agent, namespace string) ([]K8sPod, error) {
+	kubeObjects, err := findLiveObjects(enums.POD, companyId, agent, namespace)
+	if err != nil {
+		return nil, err
+	}
+	pods := []K8sPod{}
+	for _, each := range kubeObjects {
+		pods = append(pods, each.(*Pod).Obj)
+	}
+	return pods, nil
+}
+
+// selects returns true if the service selector matches labels of the pod. As in kubernetes, an empty selector selects nothing.
+func (s K8sService) selects(pod K8sPod) bool {
+	if len(s.Spec.Selector) == 0 || s.Namespace != pod.Namespace {
+		return false
+	}
+	for key, value := range s.Spec.Selector {
+		if labelValue, ok := pod.Labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ready returns true if the pod is ready to serve requests.
+func (p K8sPod) ready() bool {
+	if p.DeletionTimestamp != nil {
+		return false
+	}
+	for _, each := range p.Status.Conditions {
+		if each.Type == PodReady {
+			return each.Status == ConditionTrue
+		}
+	}
+	return false
+}
+
+// containerPort returns number of a named container port of the pod.
+func (p K8sPod) containerPort(name string) (int32, bool) {
+	for _, container := range p.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return port.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// endpoint returns the pod as an endpoint of the service, resolving target ports against container ports.
+func (s K8sService) endpoint(pod K8sPod) ServiceEndpoint {
+	endpoint := ServiceEndpoint{
+		Pod:      pod.Name,
+		IP:       pod.Status.PodIP,
+		NodeName: pod.Spec.NodeName,
+		Ready:    pod.ready(),
+		Ports:    []EndpointPort{},
+	}
+	for _, each := range s.Spec.Ports {
+		port := EndpointPort{Name: each.Name, Port: each.Port, Protocol: each.Protocol}
+		switch {
+		case each.TargetPort.Type == intstr.String && each.TargetPort.StrVal != "":
+			target, ok := pod.containerPort(each.TargetPort.StrVal)
+			if !ok {
+				endpoint.UnresolvedPorts = append(endpoint.UnresolvedPorts, each.TargetPort.StrVal)
+				continue
+			}
+			port.TargetPort = target
+		case each.TargetPort.IntVal != 0:
+			port.TargetPort = each.TargetPort.IntVal
+		default:
+			port.TargetPort = each.Port
+		}
+		endpoint.Ports = append(endpoint.Ports, port)
+	}
+	return endpoint
+}
+
+// endpoints returns endpoints of the service among pods.
+func (s K8sService) endpoints(pods []K8sPod) ServiceEndpoints {
+	result := ServiceEndpoints{
+		Service:   s.Name,
+		Namespace: s.Namespace,
+		Type:      s.Spec.Type,
+		Selector:  s.Spec.Selector,
+		Endpoints: []ServiceEndpoint{},
+		NotReady:  []ServiceEndpoint{},
+	}
+	for _, pod := range pods {
+		if !s.selects(pod) {
+			continue
+		}
+		endpoint := s.endpoint(pod)
+		if endpoint.Ready {
+			result.Endpoints = append(result.Endpoints, endpoint)
+		} else {
+			result.NotReady = append(result.NotReady, endpoint)
+		}
+	}
+	return result
+}
+
+// ResolveServiceEndpoints returns pods selected by a stored service along with resolved container ports.
+func ResolveServiceEndpoints(companyId, agent, namespace, name string) (ServiceEndpoints, error) {
+	services, err := findServices(companyId, agent, namespace)
+	if err != nil {
+		return ServiceEndpoints{}, err
+	}
+	for _, service := range services {
+		if service.Name != name {
+			continue
+		}
+		pods, err := findPods(companyId, agent, namespace)
+		if err != nil {
+			return ServiceEndpoints{}, err
+		}
+		return service.endpoints(pods), nil
+	}
+	return ServiceEndpoints{}, ErrResourceNotFound
+}
+
+// AnalyzeServiceRouting returns endpoints of every stored service of an agent, optionally in a namespace, reporting
+// services selecting no pod and pods no service selects.
+func AnalyzeServiceRouting(companyId, agent, namespace string) (ServiceRoutingReport, error) {
+	services, err := findServices(companyId, agent, namespace)
+	if err != nil {
+		return ServiceRoutingReport{}, err
+	}
+	pods, err := findPods(companyId, agent, namespace)
+	if err != nil {
+		return ServiceRoutingReport{}, err
+	}
+	report := ServiceRoutingReport{
+		Services:                 []ServiceEndpoints{},
+		ServicesWithoutSelector:  []ObjectName{},
+		ServicesSelectingNothing: []ObjectName{},
+		UnselectedPods:           []ObjectName{},
+	}
+	selected := make(map[ObjectName]bool)
+	for _, service := range services {
+		name := ObjectName{Namespace: service.Namespace, Name: service.Name}
+		if len(service.Spec.Selector) == 0 {
+			report.ServicesWithoutSelector = append(report.ServicesWithoutSelector, name)
+			continue
+		}
+		endpoints := service.endpoints(pods)
+		report.Services = append(report.Services, endpoints)
+		if len(endpoints.Endpoints) == 0 && len(endpoints.NotReady) == 0 {
+			report.ServicesSelectingNothing = append(report.ServicesSelectingNothing, name)
+		}
+		for _, each := range append(endpoints.Endpoints, endpoints.NotReady...) {
+			selected[ObjectName{Namespace: service.Namespace, Name: each.Pod}] = true
+		}
+	}
+	for _, pod := range pods {
+		name := ObjectName{Namespace: pod.Namespace, Name: pod.Name}
+		if !selected[name] {
+			report.UnselectedPods = append(report.UnselectedPods, name)
+		}
+	}
+	sort.Slice(report.Services, func(i, j int) bool {
+		if report.Services[i].Namespace != report.Services[j].Namespace {
+			return report.Services[i].Namespace < report.Services[j].Namespace
+		}
+		return report.Services[i].Service < report.Services[j].Service
+	})
+	return report, nil
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"time"
+)
+
+// agentObjectsQuery matches every stored object reported by an agent of a company. Most objects carry no company
+// label, so objects are matched by agent name, skipping only those labeled with another company.
+func agentObjectsQuery(companyId, agent string) bson.M {
+	return bson.M{
+		"$and": []bson.M{
+			{"agent_name": agent},
+			{"obj.metadata.labels.company": bson.M{"$in": []interface{}{companyId, nil, ""}}},
+		},
+	}
+}
+
+// StartStaleAgentDetector periodically detects stale agents. Blocks forever.
+func StartStaleAgentDetector() {
+	interval := config.StaleAgentCheckInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		DetectStaleAgents()
+	}
+}
+
+// DetectStaleAgents marks silent agents stale and their objects unverified, removes objects of agents
+// silent longer than purge threshold and restores agents that came back.
+func DetectStaleAgents() {
+	now := time.Now().UTC()
+	for _, agent := range FindAgents("") {
+		silence := now.Sub(agent.LastSeen)
+		switch {
+		case silence <= config.AgentStaleAfter:
+			if agent.Status == enums.AGENT_STALE || agent.Status == enums.AGENT_PURGED {
+				agent.setObjectsUnverified(false, now)
+				agent.setStatus(enums.AGENT_ACTIVE, now)
+				log.Println("[INFO] Agent", agent.Name, "of company", agent.CompanyId, "is active again")
+			}
+		case silence > config.AgentPurgeAfter && agent.Status != enums.AGENT_PURGED && enums.RETENTION_POLICY(config.AgentPurgePolicy) != enums.RETAIN:
+			if agent.Status != enums.AGENT_STALE {
+				agent.setObjectsUnverified(true, now)
+			}
+			removed, err := agent.removeObjects(enums.RETENTION_POLICY(config.AgentPurgePolicy), "agent stale since "+agent.LastSeen.Format(time.RFC3339), nil)
+			if err != nil {
+				log.Println("[ERROR] Failed to remove objects of stale agent", agent.Name+":", err.Error())
+				continue
+			}
+			agent.setStatus(enums.AGENT_PURGED, now)
+			log.Println("[INFO] Removed", removed, "objects of stale agent", agent.Name, "of company", agent.CompanyId)
+		case agent.Status != enums.AGENT_STALE && agent.Status != enums.AGENT_PURGED:
+			agent.setObjectsUnverified(true, now)
+			agent.setStatus(enums.AGENT_STALE, now)
+			log.Println("[INFO] Agent", agent.Name, "of company", agent.CompanyId, "is stale")
+		}
+	}
+}
+
+func (a Agent) setStatus(status enums.AGENT_STATUS, now time.Time) {
+	coll := db.GetDmManager().Db.Collection(AgentCollection)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, agentFilter(a.CompanyId, a.Name), bson.M{
+		"$set": bson.M{"status": status, "status_changed_at": now},
+	})
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+}
+
+// setObjectsUnverified flags or unflags every stored object of the agent as unverified.
+func (a Agent) setObjectsUnverified(unverified bool, now time.Time) {
+	update := bson.M{"$unset": bson.M{"unverified": "", "unverified_since": ""}}
+	if unverified {
+		update = bson.M{"$set": bson.M{"unverified": true, "unverified_since": now}}
+	}
+	for _, each := range Resources {
+		coll := db.GetDmManager().Db.Collection(each.Collection)
+		_, err := coll.UpdateMany(db.GetDmManager().Ctx, agentObjectsQuery(a.CompanyId, a.Name), update)
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+	}
+}
+
+// removeObjects archives or purges every stored object of the agent. progress, if not nil, is called after each collection.
+func (a Agent) removeObjects(policy enums.RETENTION_POLICY, reason string, progress func(collection string, removed int64)) (int64, error) {
+	var total int64
+	for _, each := range Resources {
+		removed, err := removeObjects(each.Collection, agentObjectsQuery(a.CompanyId, a.Name), policy, reason)
+		total += removed
+		if err != nil {
+			return total, err
+		}
+		if progress != nil {
+			progress(each.Collection, removed)
+		}
+	}
+	return total, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/go-bongo/bongo"
 	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 	"log"
@@ -25,6 +26,7 @@ type StatefulSet struct {
 	bongo.DocumentBase `bson:",inline"`
 	Obj                K8sStatefulSet `bson:"obj" json:"obj"`
 	AgentName          string         `bson:"agent_name" json:"agent_name"`
+	Tombstone          `bson:",inline"`
 }
 
 func (obj StatefulSet) deleteAll() error {
@@ -44,6 +46,7 @@ func NewStatefulSet() KubeObject {
 
 func (obj StatefulSet) Save(extra map[string]string) error {
 	obj.AgentName = extra["agent_name"]
+	Redact(enums.STATEFULSET, &obj.Obj)
 	if obj.findByNameAndNamespaceAndCompanyId().Name == "" {
 		coll := db.GetDmManager().Db.Collection(StatefulSetCollection)
 		_, err := coll.InsertOne(db.GetDmManager().Ctx, obj)
@@ -99,7 +102,9 @@ func (obj StatefulSet) findByNameAndNamespaceAndCompanyId() K8sStatefulSet {
 	return temp.Obj
 }
 
-func (obj StatefulSet) Delete(agent string) error {
+func (obj StatefulSet) Delete(extra map[string]string) error {
+	agent := extra["agent_name"]
+	Redact(enums.STATEFULSET, &obj.Obj)
 	query := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
@@ -108,7 +113,7 @@ func (obj StatefulSet) Delete(agent string) error {
 		},
 	}
 	coll := db.GetDmManager().Db.Collection(StatefulSetCollection)
-	_, err := coll.DeleteOne(db.GetDmManager().Ctx, query)
+	_, err := coll.UpdateOne(db.GetDmManager().Ctx, query, tombstoneUpdate(obj.Obj, extra))
 	if err != nil {
 		log.Println("[ERROR]", err)
 	}
@@ -125,6 +130,7 @@ func (obj StatefulSet) Update(oldObj interface{}, agent string) error {
 	if obj.AgentName == "" {
 		obj.AgentName = agent
 	}
+	Redact(enums.STATEFULSET, &obj.Obj)
 	filter := bson.M{
 		"$and": []bson.M{
 			{"obj.metadata.namespace": obj.Obj.Namespace},
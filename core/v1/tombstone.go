@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Tombstone deletion state of a stored object. Deleted objects are kept with their last known state until
+// TombstoneRetention has passed, storing the object again revives it.
+type Tombstone struct {
+	Deleted        bool       `json:"deleted,omitempty" bson:"deleted"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" bson:"deleted_at"`
+	DeletionOffset *int       `json:"deletion_offset,omitempty" bson:"deletion_offset"`
+}
+
+// notDeleted matches stored objects that are not tombstones.
+var notDeleted = bson.M{"deleted": bson.M{"$ne": true}}
+
+// tombstoneUpdate returns update marking a stored object deleted, keeping obj as its last known state.
+// extra may carry offset of the deleting command.
+func tombstoneUpdate(obj interface{}, extra map[string]string) bson.M {
+	set := bson.M{
+		"obj":        obj,
+		"deleted":    true,
+		"deleted_at": time.Now().UTC(),
+	}
+	if offset, err := strconv.Atoi(extra["offset"]); err == nil {
+		set["deletion_offset"] = offset
+	}
+	return bson.M{"$set": set}
+}
+
+// StartTombstonePurger periodically removes tombstones older than retention. Blocks forever.
+func StartTombstonePurger() {
+	interval := config.TombstonePurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		PurgeTombstones()
+	}
+}
+
+// PurgeTombstones removes tombstones older than TombstoneRetention across resource collections.
+func PurgeTombstones() {
+	if config.TombstoneRetention <= 0 {
+		return
+	}
+	query := bson.M{
+		"$and": []bson.M{
+			{"deleted": true},
+			{"deleted_at": bson.M{"$lt": time.Now().UTC().Add(-config.TombstoneRetention)}},
+		},
+	}
+	for _, each := range Resources {
+		result, err := db.GetDmManager().Db.Collection(each.Collection).DeleteMany(db.GetDmManager().Ctx, query)
+		if err != nil {
+			log.Println("[ERROR] Failed to purge tombstones of", each.Collection+":", err.Error())
+			continue
+		}
+		if result.DeletedCount > 0 {
+			log.Println("[INFO] Purged", result.DeletedCount, "tombstones of", each.Collection)
+		}
+	}
+}
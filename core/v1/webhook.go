@@ -0,0 +1,284 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const WebhookCollection = "webhookCollection"
+
+// webhookRefreshInterval refers to how often subscriptions are reloaded, so changes made through other instances apply.
+const webhookRefreshInterval = time.Minute
+
+// ErrWebhookNotFound returned when a webhook subscription does not exist.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook subscriber of change events of stored objects of a company, optionally limited to an agent, kinds and
+// namespaces. Empty kinds or namespaces match every kind or namespace.
+type Webhook struct {
+	Id         string                `json:"id" bson:"id"`
+	CompanyId  string                `json:"company" bson:"company"`
+	AgentName  string                `json:"agent_name,omitempty" bson:"agent_name"`
+	Kinds      []enums.RESOURCE_TYPE `json:"kinds" bson:"kinds"`
+	Namespaces []string              `json:"namespaces" bson:"namespaces"`
+	Url        string                `json:"url" bson:"url"`
+	// Secret key of the hmac signature of deliveries, never returned.
+	Secret string `json:"secret,omitempty" bson:"secret"`
+	// Active defaults to true on create and is kept on update if omitted.
+	Active    *bool     `json:"active,omitempty" bson:"active"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+func (w Webhook) validate() error {
+	if w.CompanyId == "" {
+		return errors.New("company is required")
+	}
+	target, err := url.Parse(w.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	for _, each := range w.Kinds {
+		if _, ok := GetResourceDescriptor(each); !ok {
+			return ErrUnknownResource
+		}
+	}
+	return nil
+}
+
+// masked returns the webhook without its secret, for responses.
+func (w Webhook) masked() Webhook {
+	if w.Secret != "" {
+		w.Secret = RedactedValue
+	}
+	return w
+}
+
+// matches returns true if the webhook subscribes to the applied event.
+func (w Webhook) matches(event AppliedKubeEvent) bool {
+	if w.Active == nil || !*w.Active || w.CompanyId != event.CompanyId {
+		return false
+	}
+	if w.AgentName != "" && w.AgentName != event.AgentName {
+		return false
+	}
+	if len(w.Kinds) > 0 {
+		found := false
+		for _, each := range w.Kinds {
+			if each == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(w.Namespaces) > 0 {
+		for _, each := range w.Namespaces {
+			if each == event.Meta.Namespace {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Create stores the webhook subscription.
+func (w Webhook) Create() (Webhook, error) {
+	if err := w.validate(); err != nil {
+		return Webhook{}, err
+	}
+	w.Id = primitive.NewObjectID().Hex()
+	if w.Active == nil {
+		active := true
+		w.Active = &active
+	}
+	w.CreatedAt = time.Now().UTC()
+	w.UpdatedAt = w.CreatedAt
+	if w.Kinds == nil {
+		w.Kinds = []enums.RESOURCE_TYPE{}
+	}
+	if w.Namespaces == nil {
+		w.Namespaces = []string{}
+	}
+	_, err := db.GetDmManager().Db.Collection(WebhookCollection).InsertOne(db.GetDmManager().Ctx, w)
+	if err != nil {
+		log.Println("[ERROR] Insert document:", err.Error())
+		return Webhook{}, err
+	}
+	getWebhookRegistry().invalidate()
+	return w.masked(), nil
+}
+
+// Update updates filters, url, secret and activeness of the webhook subscription. Secret is kept if empty or masked as
+// returned by FindWebhook, activeness if omitted.
+func (w Webhook) Update() (Webhook, error) {
+	if err := w.validate(); err != nil {
+		return Webhook{}, err
+	}
+	if w.Kinds == nil {
+		w.Kinds = []enums.RESOURCE_TYPE{}
+	}
+	if w.Namespaces == nil {
+		w.Namespaces = []string{}
+	}
+	set := bson.M{
+		"agent_name": w.AgentName,
+		"kinds":      w.Kinds,
+		"namespaces": w.Namespaces,
+		"url":        w.Url,
+		"updated_at": time.Now().UTC(),
+	}
+	if w.Secret != "" && w.Secret != RedactedValue {
+		set["secret"] = w.Secret
+	}
+	if w.Active != nil {
+		set["active"] = *w.Active
+	}
+	after := options.After
+	opt := options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}
+	coll := db.GetDmManager().Db.Collection(WebhookCollection)
+	result := coll.FindOneAndUpdate(db.GetDmManager().Ctx, bson.M{"id": w.Id, "company": w.CompanyId}, bson.M{"$set": set}, &opt)
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return Webhook{}, ErrWebhookNotFound
+		}
+		log.Println("[ERROR]", result.Err())
+		return Webhook{}, result.Err()
+	}
+	var webhook Webhook
+	if err := result.Decode(&webhook); err != nil {
+		return Webhook{}, err
+	}
+	getWebhookRegistry().invalidate()
+	return webhook.masked(), nil
+}
+
+// Delete removes the webhook subscription, its delivery log is kept until it expires.
+func (w Webhook) Delete() error {
+	coll := db.GetDmManager().Db.Collection(WebhookCollection)
+	result, err := coll.DeleteOne(db.GetDmManager().Ctx, bson.M{"id": w.Id, "company": w.CompanyId})
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	getWebhookRegistry().invalidate()
+	return nil
+}
+
+// FindWebhook returns a webhook subscription of a company.
+func FindWebhook(companyId, id string) (Webhook, error) {
+	var webhook Webhook
+	err := db.GetDmManager().Db.Collection(WebhookCollection).FindOne(db.GetDmManager().Ctx, bson.M{"id": id, "company": companyId}).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Webhook{}, ErrWebhookNotFound
+		}
+		log.Println("[ERROR]", err)
+		return Webhook{}, err
+	}
+	return webhook.masked(), nil
+}
+
+// findWebhookById returns a webhook subscription including its secret.
+func findWebhookById(id string) (Webhook, error) {
+	var webhook Webhook
+	err := db.GetDmManager().Db.Collection(WebhookCollection).FindOne(db.GetDmManager().Ctx, bson.M{"id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+// FindWebhooks returns webhook subscriptions of a company.
+func FindWebhooks(companyId string) []Webhook {
+	webhooks := []Webhook{}
+	for _, each := range findWebhooks(companyId) {
+		webhooks = append(webhooks, each.masked())
+	}
+	return webhooks
+}
+
+// findWebhooks returns webhook subscriptions including their secrets of a company, all subscriptions if companyId is
+// empty.
+func findWebhooks(companyId string) []Webhook {
+	query := bson.M{}
+	if companyId != "" {
+		query["company"] = companyId
+	}
+	webhooks := []Webhook{}
+	coll := db.GetDmManager().Db.Collection(WebhookCollection)
+	curser, err := coll.Find(db.GetDmManager().Ctx, query, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return webhooks
+	}
+	defer curser.Close(context.TODO())
+	for curser.Next(context.TODO()) {
+		var webhook Webhook
+		if err := curser.Decode(&webhook); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks
+}
+
+// webhookRegistry cached subscriptions, so applying kube events does not query them every time.
+type webhookRegistry struct {
+	mu       sync.Mutex
+	webhooks []Webhook
+	loadedAt time.Time
+}
+
+var singletonWebhookRegistry *webhookRegistry
+var onceWebhookRegistry sync.Once
+
+func getWebhookRegistry() *webhookRegistry {
+	onceWebhookRegistry.Do(func() {
+		singletonWebhookRegistry = &webhookRegistry{}
+	})
+	return singletonWebhookRegistry
+}
+
+func (r *webhookRegistry) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadedAt = time.Time{}
+}
+
+// subscribers returns active webhooks subscribing to the applied event.
+func (r *webhookRegistry) subscribers(event AppliedKubeEvent) []Webhook {
+	r.mu.Lock()
+	if time.Since(r.loadedAt) > webhookRefreshInterval {
+		r.webhooks = findWebhooks("")
+		r.loadedAt = time.Now()
+	}
+	webhooks := r.webhooks
+	r.mu.Unlock()
+	subscribers := []Webhook{}
+	for _, each := range webhooks {
+		if each.matches(event) {
+			subscribers = append(subscribers, each)
+		}
+	}
+	return subscribers
+}
@@ -0,0 +1,380 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/klovercloud-ci-cd/light-house-command/config"
+	"github.com/klovercloud-ci-cd/light-house-command/core/v1/db"
+	"github.com/klovercloud-ci-cd/light-house-command/enums"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const WebhookDeliveryCollection = "webhookDeliveryCollection"
+
+const (
+	// CloudEventsSpecVersion version of the cloudevents spec change events follow.
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType content type of cloudevents in structured json mode.
+	CloudEventsContentType = "application/cloudevents+json"
+	// changeEventTypePrefix prefix of change event types, followed by added, modified or deleted.
+	changeEventTypePrefix = "io.klovercloud.lighthouse.object."
+	// SignatureHeader header carrying hex encoded hmac sha256 of the timestamp, a dot and the body, prefixed with sha256=.
+	SignatureHeader = "X-Lighthouse-Signature"
+	// TimestampHeader header carrying unix time in seconds of the attempt, signed with the body so receivers can reject
+	// replayed deliveries.
+	TimestampHeader = "X-Lighthouse-Timestamp"
+)
+
+// ChangeEventData data of a change event.
+type ChangeEventData struct {
+	Kind            enums.RESOURCE_TYPE `json:"kind"`
+	CompanyId       string              `json:"company"`
+	AgentName       string              `json:"agent_name"`
+	Namespace       string              `json:"namespace,omitempty"`
+	Name            string              `json:"name"`
+	UID             string              `json:"uid"`
+	ResourceVersion string              `json:"resource_version"`
+	Command         enums.Command       `json:"command"`
+	Offset          int                 `json:"offset"`
+	// Changes field changes from the previous version, set for UPDATE.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// CloudEvent change event in cloudevents 1.0 structured json format.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            ChangeEventData `json:"data"`
+}
+
+// WebhookAttempt attempt of delivering a change event.
+type WebhookAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code"`
+	Error      string    `json:"error,omitempty" bson:"error"`
+}
+
+// WebhookDelivery delivery log of a change event to a webhook.
+type WebhookDelivery struct {
+	Id         string                        `json:"id" bson:"id"`
+	WebhookId  string                        `json:"webhook_id" bson:"webhook_id"`
+	CompanyId  string                        `json:"company" bson:"company"`
+	EventId    string                        `json:"event_id" bson:"event_id"`
+	EventType  string                        `json:"event_type" bson:"event_type"`
+	Subject    string                        `json:"subject" bson:"subject"`
+	Url        string                        `json:"url" bson:"url"`
+	Status     enums.WEBHOOK_DELIVERY_STATUS `json:"status" bson:"status"`
+	Attempts   []WebhookAttempt              `json:"attempts" bson:"attempts"`
+	CreatedAt  time.Time                     `json:"created_at" bson:"created_at"`
+	FinishedAt *time.Time                    `json:"finished_at,omitempty" bson:"finished_at"`
+	// NextAttemptAt time the next attempt of a pending delivery is due.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at"`
+	// Payload change event posted to the webhook, kept so pending deliveries can be resumed after a restart.
+	Payload  string    `json:"-" bson:"payload"`
+	ExpireAt time.Time `json:"-" bson:"expire_at"`
+}
+
+// WebhookDeliveryQuery scope and page of a delivery log query.
+type WebhookDeliveryQuery struct {
+	CompanyId string
+	WebhookId string
+	Status    enums.WEBHOOK_DELIVERY_STATUS
+	Page      int64
+	Limit     int64
+}
+
+// newCloudEvent returns change event of an applied kube event.
+func newCloudEvent(event AppliedKubeEvent) (CloudEvent, bool) {
+	descriptor, ok := GetResourceDescriptor(event.Type)
+	eventType := kubeWatchEventType(event.Command)
+	if !ok || eventType == "" {
+		return CloudEvent{}, false
+	}
+	subject := descriptor.Kind + "/" + event.Meta.Name
+	if event.Meta.Namespace != "" {
+		subject = descriptor.Kind + "/" + event.Meta.Namespace + "/" + event.Meta.Name
+	}
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Id:              primitive.NewObjectID().Hex(),
+		Source:          "/companies/" + event.CompanyId + "/agents/" + event.AgentName,
+		Type:            changeEventTypePrefix + strings.ToLower(eventType),
+		Subject:         subject,
+		Time:            event.AppliedAt,
+		DataContentType: "application/json",
+		Data: ChangeEventData{
+			Kind:            event.Type,
+			CompanyId:       event.CompanyId,
+			AgentName:       event.AgentName,
+			Namespace:       event.Meta.Namespace,
+			Name:            event.Meta.Name,
+			UID:             string(event.Meta.UID),
+			ResourceVersion: event.Meta.ResourceVersion,
+			Command:         event.Command,
+			Offset:          event.Offset,
+			Changes:         event.Changes,
+		},
+	}, true
+}
+
+// signPayload returns hex encoded hmac sha256 of the timestamp, a dot and the body keyed by secret.
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// dispatchWebhookEvents delivers change event of the applied kube event to subscribed webhooks in background. Objects
+// carrying no company label are delivered to webhooks of the company their agent is registered by.
+func dispatchWebhookEvents(event AppliedKubeEvent) {
+	if event.CompanyId == "" {
+		event.CompanyId = AgentCompany(event.AgentName)
+	}
+	webhooks := getWebhookRegistry().subscribers(event)
+	if len(webhooks) == 0 {
+		return
+	}
+	cloudEvent, ok := newCloudEvent(event)
+	if !ok {
+		return
+	}
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return
+	}
+	coll := db.GetDmManager().Db.Collection(WebhookDeliveryCollection)
+	for _, each := range webhooks {
+		now := time.Now().UTC()
+		delivery := WebhookDelivery{
+			Id:            primitive.NewObjectID().Hex(),
+			WebhookId:     each.Id,
+			CompanyId:     each.CompanyId,
+			EventId:       cloudEvent.Id,
+			EventType:     cloudEvent.Type,
+			Subject:       cloudEvent.Subject,
+			Url:           each.Url,
+			Status:        enums.WEBHOOK_DELIVERY_PENDING,
+			Attempts:      []WebhookAttempt{},
+			CreatedAt:     now,
+			NextAttemptAt: &now,
+			Payload:       string(body),
+			ExpireAt:      now.Add(config.WebhookDeliveryRetention),
+		}
+		if _, err := coll.InsertOne(db.GetDmManager().Ctx, delivery); err != nil {
+			log.Println("[ERROR] Insert document:", err.Error())
+		}
+		go delivery.deliver(each)
+	}
+}
+
+// deliver posts the change event to the webhook, retrying failed attempts with exponential backoff. Attempts already
+// recorded for the delivery count towards WebhookMaxAttempts. Every attempt is recorded in the delivery log.
+func (d WebhookDelivery) deliver(webhook Webhook) {
+	coll := db.GetDmManager().Db.Collection(WebhookDeliveryCollection)
+	client := &http.Client{Timeout: config.WebhookTimeout}
+	attempts := int(config.WebhookMaxAttempts)
+	if attempts < 1 {
+		attempts = 1
+	}
+	if len(d.Attempts) >= attempts {
+		d.finish(enums.WEBHOOK_DELIVERY_FAILED)
+		return
+	}
+	for attempt := len(d.Attempts) + 1; attempt <= attempts; attempt++ {
+		result := postCloudEvent(client, webhook, []byte(d.Payload))
+		now := time.Now().UTC()
+		update := bson.M{"$push": bson.M{"attempts": result}}
+		backoff := config.WebhookRetryBackoff << uint(attempt-1)
+		if result.Error == "" {
+			update["$set"] = bson.M{"status": enums.WEBHOOK_DELIVERY_DELIVERED, "finished_at": now, "next_attempt_at": nil}
+		} else if attempt == attempts {
+			update["$set"] = bson.M{"status": enums.WEBHOOK_DELIVERY_FAILED, "finished_at": now, "next_attempt_at": nil}
+			log.Println("[ERROR] Failed to deliver", d.EventType, "of", d.Subject, "to webhook", webhook.Id+":", result.Error)
+		} else {
+			update["$set"] = bson.M{"next_attempt_at": now.Add(backoff)}
+		}
+		if _, err := coll.UpdateOne(db.GetDmManager().Ctx, bson.M{"id": d.Id}, update); err != nil {
+			log.Println("[ERROR]", err)
+		}
+		if result.Error == "" {
+			return
+		}
+		if attempt < attempts {
+			time.Sleep(backoff)
+		}
+	}
+}
+
+// finish closes the delivery with the status without another attempt.
+func (d WebhookDelivery) finish(status enums.WEBHOOK_DELIVERY_STATUS) {
+	update := bson.M{"$set": bson.M{"status": status, "finished_at": time.Now().UTC(), "next_attempt_at": nil}}
+	_, err := db.GetDmManager().Db.Collection(WebhookDeliveryCollection).UpdateOne(db.GetDmManager().Ctx, bson.M{"id": d.Id}, update)
+	if err != nil {
+		log.Println("[ERROR]", err)
+	}
+}
+
+// RedriveWebhookDeliveries resumes pending deliveries left by a stopped instance, those whose next attempt is overdue
+// by more than two attempt timeouts. Each delivery is claimed before it is resumed, so instances starting together do
+// not deliver it twice. Deliveries of removed or deactivated webhooks are failed.
+func RedriveWebhookDeliveries() {
+	coll := db.GetDmManager().Db.Collection(WebhookDeliveryCollection)
+	overdue := time.Now().UTC().Add(-2 * config.WebhookTimeout)
+	filter := bson.M{
+		"$and": []bson.M{
+			{"status": enums.WEBHOOK_DELIVERY_PENDING},
+			{"next_attempt_at": bson.M{"$lt": overdue}},
+		},
+	}
+	curser, err := coll.Find(db.GetDmManager().Ctx, filter)
+	if err != nil {
+		log.Println("[ERROR] Failed to find pending webhook deliveries:", err.Error())
+		return
+	}
+	defer curser.Close(context.TODO())
+	for curser.Next(context.TODO()) {
+		var delivery WebhookDelivery
+		if err := curser.Decode(&delivery); err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		now := time.Now().UTC()
+		claim := bson.M{"id": delivery.Id, "status": enums.WEBHOOK_DELIVERY_PENDING, "next_attempt_at": delivery.NextAttemptAt}
+		result, err := coll.UpdateOne(db.GetDmManager().Ctx, claim, bson.M{"$set": bson.M{"next_attempt_at": now}})
+		if err != nil {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		webhook, err := findWebhookById(delivery.WebhookId)
+		if err != nil && err != ErrWebhookNotFound {
+			log.Println("[ERROR]", err)
+			continue
+		}
+		if err == ErrWebhookNotFound || webhook.Active == nil || !*webhook.Active || delivery.Payload == "" {
+			delivery.finish(enums.WEBHOOK_DELIVERY_FAILED)
+			continue
+		}
+		go delivery.deliver(webhook)
+	}
+}
+
+func postCloudEvent(client *http.Client, webhook Webhook, body []byte) WebhookAttempt {
+	attempt := WebhookAttempt{At: time.Now().UTC()}
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", CloudEventsContentType)
+	if webhook.Secret != "" {
+		timestamp := strconv.FormatInt(attempt.At.Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+signPayload(webhook.Secret, timestamp, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = "unexpected response status " + strconv.Itoa(resp.StatusCode)
+	}
+	return attempt
+}
+
+// FindWebhookDeliveries returns a page of delivery log of a webhook, latest first, and total count of deliveries
+// matching the query.
+func FindWebhookDeliveries(q WebhookDeliveryQuery) ([]WebhookDelivery, int64, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	} else if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page < 0 {
+		q.Page = 0
+	}
+	conditions := []bson.M{
+		{"company": q.CompanyId},
+		{"webhook_id": q.WebhookId},
+	}
+	if q.Status != "" {
+		conditions = append(conditions, bson.M{"status": q.Status})
+	}
+	filter := andFilter(conditions)
+	coll := db.GetDmManager().Db.Collection(WebhookDeliveryCollection)
+	total, err := coll.CountDocuments(db.GetDmManager().Ctx, filter)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(q.Page * q.Limit).
+		SetLimit(q.Limit)
+	curser, err := coll.Find(db.GetDmManager().Ctx, filter, opts)
+	if err != nil {
+		log.Println("[ERROR]", err)
+		return nil, 0, err
+	}
+	defer curser.Close(context.TODO())
+	deliveries := []WebhookDelivery{}
+	for curser.Next(context.TODO()) {
+		var delivery WebhookDelivery
+		if err := curser.Decode(&delivery); err != nil {
+			log.Println("[ERROR]", err)
+			break
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, nil
+}
+
+// EnsureWebhookIndexes creates lookup indexes of webhooks and ttl and pending indexes of their delivery log.
+func EnsureWebhookIndexes() {
+	_, err := db.GetDmManager().Db.Collection(WebhookCollection).Indexes().CreateOne(db.GetDmManager().Ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create webhook indexes:", err.Error())
+	}
+	_, err = db.GetDmManager().Db.Collection(WebhookDeliveryCollection).Indexes().CreateMany(db.GetDmManager().Ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expire_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to create webhook delivery indexes:", err.Error())
+	}
+}
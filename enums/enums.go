@@ -77,3 +77,71 @@ const (
 	// Kube object DELETE command
 	DELETE = Command("DELETE")
 )
+
+// AGENT_STATUS agent liveness status
+type AGENT_STATUS string
+
+const (
+	// AGENT_ACTIVE agent is posting events or heartbeats
+	AGENT_ACTIVE = AGENT_STATUS("ACTIVE")
+	// AGENT_STALE agent has been silent longer than stale threshold
+	AGENT_STALE = AGENT_STATUS("STALE")
+	// AGENT_PURGED stale agent whose objects have been purged or archived
+	AGENT_PURGED = AGENT_STATUS("PURGED")
+)
+
+// RETENTION_POLICY policy of removing stored objects
+type RETENTION_POLICY string
+
+const (
+	// RETAIN keeps objects as they are
+	RETAIN = RETENTION_POLICY("RETAIN")
+	// ARCHIVE moves objects to archive collection
+	ARCHIVE = RETENTION_POLICY("ARCHIVE")
+	// PURGE deletes objects
+	PURGE = RETENTION_POLICY("PURGE")
+)
+
+// JOB_STATUS background job status
+type JOB_STATUS string
+
+const (
+	// JOB_RUNNING job is in progress
+	JOB_RUNNING = JOB_STATUS("RUNNING")
+	// JOB_COMPLETED job has finished successfully
+	JOB_COMPLETED = JOB_STATUS("COMPLETED")
+	// JOB_FAILED job has finished with error
+	JOB_FAILED = JOB_STATUS("FAILED")
+)
+
+// ORPHAN_POLICY policy of handling dependents whose owners no longer exist
+type ORPHAN_POLICY string
+
+const (
+	// ORPHAN_FLAG flags orphaned dependents
+	ORPHAN_FLAG = ORPHAN_POLICY("FLAG")
+	// ORPHAN_DELETE flags orphaned dependents and deletes them after grace period
+	ORPHAN_DELETE = ORPHAN_POLICY("DELETE")
+)
+
+// ROLLOUT_STATUS result of a workload rollout
+type ROLLOUT_STATUS string
+
+const (
+	// ROLLOUT_FINISHED every replica of the latest generation is available
+	ROLLOUT_FINISHED = ROLLOUT_STATUS("rollout-finished")
+	// ROLLOUT_FAILED rollout exceeded its progress deadline
+	ROLLOUT_FAILED = ROLLOUT_STATUS("rollout-failed")
+)
+
+// WEBHOOK_DELIVERY_STATUS status of a change event delivery to a webhook
+type WEBHOOK_DELIVERY_STATUS string
+
+const (
+	// WEBHOOK_DELIVERY_PENDING delivery is being attempted
+	WEBHOOK_DELIVERY_PENDING = WEBHOOK_DELIVERY_STATUS("PENDING")
+	// WEBHOOK_DELIVERY_DELIVERED webhook accepted the event
+	WEBHOOK_DELIVERY_DELIVERED = WEBHOOK_DELIVERY_STATUS("DELIVERED")
+	// WEBHOOK_DELIVERY_FAILED every attempt has failed
+	WEBHOOK_DELIVERY_FAILED = WEBHOOK_DELIVERY_STATUS("FAILED")
+)
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/klovercloud-ci-cd/light-house-command/api"
 	"github.com/klovercloud-ci-cd/light-house-command/config"
+	v1 "github.com/klovercloud-ci-cd/light-house-command/core/v1"
 	_ "github.com/klovercloud-ci-cd/light-house-command/docs"
 )
 
@@ -11,5 +12,13 @@ import (
 func main() {
 	e := config.New()
 	api.Routes(e)
+	go v1.StartStaleAgentDetector()
+	go v1.EnsureEventIndexes()
+	go v1.EnsureRevisionIndexes()
+	go v1.EnsurePipelineIndexes()
+	go v1.EnsureWebhookIndexes()
+	go v1.RedriveWebhookDeliveries()
+	go v1.StartTombstonePurger()
+	go v1.StartOwnerReferenceCollector()
 	e.Logger.Fatal(e.Start(":" + config.ServerPort))
 }